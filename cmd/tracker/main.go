@@ -2,32 +2,165 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	_ "time/tzdata" // the time zones of the data files on systems without a zone database
 
+	"github.com/seektor/habits-tracker-go/internal/clock"
 	"github.com/seektor/habits-tracker-go/internal/command"
 	"github.com/seektor/habits-tracker-go/internal/habits"
 	"github.com/seektor/habits-tracker-go/internal/utils"
 )
 
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
 func main() {
+	data := flag.String("data", "", "data file, or a directory with a file per profile (env "+utils.DataEnv+")")
+	profile := flag.String("profile", "", "name of the profile, e.g. work (env "+utils.ProfileEnv+")")
+	// Hidden, moves the clock to reproduce the day rollover, e.g. --now 2020-11-30
+	now := flag.String("now", "", "start time of the clock, 2006-01-02, 2006-01-02T15:04 or RFC 3339")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: tracker [flags] [command] [arguments]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Runs the interactive mode when no command is given.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		printVisibleDefaults("now")
+		fmt.Fprintln(flag.CommandLine.Output())
+		habits.NewHabits(clock.System{}).PrintCommands()
+	}
+	flag.Parse()
+
+	var clk clock.Clock = clock.System{}
+
+	if *now != "" {
+		start, err := clock.Parse(*now)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --now:", err)
+			os.Exit(exitUsage)
+		}
+
+		clk = clock.NewShifted(start)
+	}
+
+	path, err := utils.GetDataPath(*data, *profile)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	if flag.NArg() > 0 {
+		os.Exit(run(path, clk, flag.Args()))
+	}
+
+	runInteractive(path, clk)
+}
+
+// printVisibleDefaults prints the defaults of the flags except the hidden ones.
+func printVisibleDefaults(hidden ...string) {
+	visible := flag.NewFlagSet(flag.CommandLine.Name(), flag.ContinueOnError)
+	visible.SetOutput(flag.CommandLine.Output())
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(hidden, f.Name) {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+
+	visible.PrintDefaults()
+}
+
+// newConfirm asks a yes/no question and reads the answer, anything but y means no.
+func newConfirm(reader *bufio.Reader) func(question string) bool {
+	return func(question string) bool {
+		fmt.Printf("%s [y/N]: ", question)
+		answer, _ := reader.ReadString('\n')
+
+		return strings.EqualFold(strings.TrimSpace(answer), "y")
+	}
+}
+
+// run executes a single command given as program arguments, e.g. `tracker check read`.
+func run(path string, clk clock.Clock, args []string) int {
+	habits := habits.NewHabits(clk)
+	habits.Confirm = newConfirm(bufio.NewReader(os.Stdin))
+	defer habits.Close()
+
+	if err := habits.Load(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	if _, err := habits.Refresh(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	err := habits.Execute(command.NewCommandFromArgs(args))
+
+	var usageErr command.UsageError
+	switch {
+	case err == nil || errors.Is(err, command.ErrQuit):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Run `tracker help` to see the available commands")
+		return exitUsage
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+}
+
+func runInteractive(path string, clk clock.Clock) {
 	fmt.Println(utils.FgColors.Yellow + utils.FgColors.Bold +
 		"=== Habit Tracker ===" +
 		utils.FgColors.Reset)
 
-	habits := habits.NewHabits()
+	reader := bufio.NewReader(os.Stdin)
+	habits := habits.NewHabits(clk)
+	habits.Confirm = newConfirm(reader)
+	defer habits.Close()
 
-	if err := habits.Load(); err != nil {
+	if err := habits.Load(path); err != nil {
 		fmt.Println(utils.FgColors.Red + err.Error())
 		os.Exit(1)
 	}
 
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(utils.LegacyFileName); err == nil {
+			utils.PrintlnInfo(fmt.Sprintf("Found %s in the current directory, move it to %s to keep your habits", utils.LegacyFileName, path))
+		}
+	}
+
 	fmt.Println()
-	isUpdated := habits.UpdateToPresent()
+	daysDiff, err := habits.Refresh()
+
+	switch {
+	case daysDiff < 0:
+		utils.PrintlnError("The last update is in the future, check the system date")
+	case daysDiff == 0:
+		utils.PrintlnInfo("Nothing to update")
+	case daysDiff == 1:
+		utils.PrintlnInfo("Updating: 1 day has passed")
+	default:
+		utils.PrintlnInfo(fmt.Sprintf("Updating: %d days have passed", daysDiff))
+	}
 	fmt.Println()
 
-	if isUpdated {
-		habits.Save(utils.FileName)
+	if err != nil {
+		utils.PrintlnError("Habits have not been saved: " + err.Error())
+		fmt.Println()
+	} else if daysDiff > 0 {
 		utils.PrintlnSuccess("Habits have been updated")
 		fmt.Println()
 	}
@@ -38,8 +171,6 @@ func main() {
 		habits.PrintCommands()
 	}
 
-	reader := bufio.NewReader(os.Stdin)
-
 	for {
 		fmt.Println()
 		fmt.Print("Enter command: ")
@@ -51,7 +182,20 @@ func main() {
 			return
 		}
 
-		command := command.NewCommand(input)
-		habits.Execute(command)
+		err = habits.Execute(command.NewCommand(input))
+
+		switch {
+		case err == nil:
+		case errors.Is(err, command.ErrQuit):
+			utils.PrintlnSuccess("Bye bye")
+			return
+		case errors.Is(err, command.ErrUnknownCommand):
+			fmt.Println()
+			utils.PrintlnError(err.Error())
+			fmt.Println()
+			habits.PrintCommands()
+		default:
+			utils.PrintlnError(err.Error())
+		}
 	}
 }
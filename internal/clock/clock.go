@@ -0,0 +1,81 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time, so the day rollover can be simulated.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the clock of the operating system.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Shifted is the system clock moved to another start time, it keeps ticking from there.
+type Shifted struct {
+	offset time.Duration
+}
+
+func NewShifted(start time.Time) Shifted {
+	return Shifted{offset: time.Until(start)}
+}
+
+func (s Shifted) Now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// Fake is a clock which stands still until it is set or advanced.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// AdvanceDays moves the clock by calendar days, keeping the time of the day.
+func (f *Fake) AdvanceDays(days int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.AddDate(0, 0, days)
+}
+
+// Parse reads a time given as 2006-01-02, 2006-01-02T15:04 in the local time zone or RFC 3339.
+func Parse(value string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
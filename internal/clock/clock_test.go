@@ -0,0 +1,61 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2020, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	t.Run("stands still until advanced", func(t *testing.T) {
+		fake := NewFake(start)
+
+		if !fake.Now().Equal(start) {
+			t.Errorf("expected %v, got %v", start, fake.Now())
+		}
+
+		fake.AdvanceDays(10)
+		fake.Advance(time.Hour)
+
+		if want := start.AddDate(0, 0, 10).Add(time.Hour); !fake.Now().Equal(want) {
+			t.Errorf("expected %v, got %v", want, fake.Now())
+		}
+	})
+}
+
+func TestShifted(t *testing.T) {
+	start := time.Date(2020, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	t.Run("starts at the given time", func(t *testing.T) {
+		now := NewShifted(start).Now()
+
+		if now.Before(start) || now.Sub(start) > time.Minute {
+			t.Errorf("expected about %v, got %v", start, now)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]time.Time{
+		"2020-11-20":           time.Date(2020, 11, 20, 0, 0, 0, 0, time.Local),
+		"2020-11-20T22:30":     time.Date(2020, 11, 20, 22, 30, 0, 0, time.Local),
+		"2020-11-20T22:30:00Z": time.Date(2020, 11, 20, 22, 30, 0, 0, time.UTC),
+	}
+
+	for value, want := range tests {
+		t.Run(value, func(t *testing.T) {
+			got, err := Parse(value)
+
+			if err != nil || !got.Equal(want) {
+				t.Errorf("expected %v, got %v (%v)", want, got, err)
+			}
+		})
+	}
+
+	t.Run("rejects an invalid time", func(t *testing.T) {
+		if _, err := Parse("yesterday"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -5,31 +5,50 @@ import (
 	"strings"
 )
 
+// ErrQuit is returned by a command which ends the session.
+var ErrQuit = errors.New("quit")
+
+var ErrUnknownCommand = NewUsageError("unknown command")
+
+// UsageError reports a command called with invalid arguments.
+type UsageError struct {
+	msg string
+}
+
+func NewUsageError(msg string) UsageError {
+	return UsageError{msg}
+}
+
+func (e UsageError) Error() string {
+	return e.msg
+}
+
 type Command struct {
 	Command string
-	args    []string
+	tokens  []string // arguments and flags in their original order, parsed by the Registry
 }
 
 func NewCommand(input string) Command {
-	inputArgs := strings.Fields(input)
+	return NewCommandFromArgs(strings.Fields(input))
+}
+
+// NewCommandFromArgs creates a command from already split arguments, e.g. os.Args.
+func NewCommandFromArgs(inputArgs []string) Command {
 	command := ""
-	args := []string{}
+	tokens := []string{}
 
 	if len(inputArgs) >= 1 {
 		command = inputArgs[0]
 	}
 
 	if len(inputArgs) > 1 {
-		args = inputArgs[1:]
+		tokens = inputArgs[1:]
 	}
 
-	return Command{command, args}
+	return Command{command, tokens}
 }
 
-func (c Command) GetArg(idx int) (string, error) {
-	if idx >= 0 && idx < len(c.args) {
-		return c.args[idx], nil
-	}
-
-	return "", errors.New("index out of range")
+// String returns the command with its arguments and flags as they have been given.
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Command}, c.tokens...), " ")
 }
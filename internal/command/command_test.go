@@ -0,0 +1,22 @@
+package command
+
+import "testing"
+
+func TestNewCommand(t *testing.T) {
+
+	t.Run("splits the command and its arguments", func(t *testing.T) {
+		command := NewCommandFromArgs([]string{"list", "--json", "read"})
+
+		if command.Command != "list" || len(command.tokens) != 2 || command.tokens[1] != "read" {
+			t.Errorf("invalid command: %+v", command)
+		}
+	})
+
+	t.Run("keeps the original input", func(t *testing.T) {
+		command := NewCommand("  d  read --force \n")
+
+		if command.String() != "d read --force" {
+			t.Errorf("expected %q, got %q", "d read --force", command.String())
+		}
+	})
+}
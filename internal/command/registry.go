@@ -0,0 +1,332 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+type ArgType int8
+
+const (
+	String ArgType = iota
+	Int
+	Int8
+	Int16
+)
+
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Min      int // Min and Max limit numeric arguments, both 0 means the range of the type
+	Max      int
+	Desc     string
+}
+
+type Flag struct {
+	Name  string
+	Value string // name of the value, empty for a boolean flag
+	Desc  string
+}
+
+type Handler func(args Args) error
+
+type Spec struct {
+	Name    string
+	Aliases []string
+	Args    []Arg
+	Flags   []Flag
+	Desc    string
+	Handler Handler
+}
+
+// Args holds the parsed arguments and flags of a command.
+type Args struct {
+	values map[string]any
+	flags  map[string]string
+}
+
+func (a Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+func (a Args) String(name string) string {
+	value, _ := a.values[name].(string)
+	return value
+}
+
+func (a Args) Int(name string) int {
+	value, _ := a.values[name].(int)
+	return value
+}
+
+func (a Args) Int8(name string) int8 {
+	value, _ := a.values[name].(int8)
+	return value
+}
+
+func (a Args) Int16(name string) int16 {
+	value, _ := a.values[name].(int16)
+	return value
+}
+
+func (a Args) HasFlag(name string) bool {
+	_, ok := a.flags[name]
+	return ok
+}
+
+func (a Args) Flag(name string) string {
+	return a.flags[name]
+}
+
+type Registry struct {
+	specs []Spec
+}
+
+// NewRegistry creates a registry with the built-in help command.
+func NewRegistry() *Registry {
+	r := &Registry{}
+
+	r.Register(Spec{
+		Name:    "h",
+		Aliases: []string{"help"},
+		Args:    []Arg{{Name: "command", Optional: true}},
+		Desc:    "Print all commands / help of a command",
+		Handler: func(args Args) error {
+			if !args.Has("command") {
+				fmt.Println(r.Table())
+				return nil
+			}
+
+			help, err := r.Help(args.String("command"))
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(help)
+			return nil
+		},
+	})
+
+	return r
+}
+
+func (r *Registry) Register(spec Spec) {
+	r.specs = append(r.specs, spec)
+}
+
+func (r *Registry) Find(name string) (Spec, bool) {
+	idx := slices.IndexFunc(r.specs, func(spec Spec) bool {
+		return spec.Name == name || slices.Contains(spec.Aliases, name)
+	})
+
+	if idx < 0 {
+		return Spec{}, false
+	}
+
+	return r.specs[idx], true
+}
+
+// Execute validates the arguments of the command and runs its handler.
+func (r *Registry) Execute(c Command) error {
+	spec, ok := r.Find(c.Command)
+
+	if !ok {
+		return ErrUnknownCommand
+	}
+
+	args, err := spec.parse(c.tokens)
+
+	if err != nil {
+		return NewUsageError(fmt.Sprintf("%s\nusage: %s", err.Error(), spec.Usage()))
+	}
+
+	return spec.Handler(args)
+}
+
+func (s Spec) parse(tokens []string) (Args, error) {
+	args := Args{values: map[string]any{}, flags: map[string]string{}}
+	positional := []string{}
+
+	for idx := 0; idx < len(tokens); idx++ {
+		name, isFlag := strings.CutPrefix(tokens[idx], "--")
+
+		if !isFlag || name == "" {
+			positional = append(positional, tokens[idx])
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		flagIdx := slices.IndexFunc(s.Flags, func(flag Flag) bool { return flag.Name == name })
+
+		if flagIdx < 0 {
+			return args, fmt.Errorf("unknown flag --%s", name)
+		}
+
+		if s.Flags[flagIdx].Value != "" && !hasValue {
+			if idx+1 >= len(tokens) {
+				return args, fmt.Errorf("missing value of --%s", name)
+			}
+
+			idx += 1
+			value = tokens[idx]
+		}
+
+		args.flags[name] = value
+	}
+
+	if len(positional) > len(s.Args) {
+		return args, fmt.Errorf("too many arguments")
+	}
+
+	for idx, arg := range s.Args {
+		if idx >= len(positional) {
+			if !arg.Optional {
+				return args, fmt.Errorf("missing argument [%s]", arg.Name)
+			}
+
+			continue
+		}
+
+		value, err := arg.parse(positional[idx])
+
+		if err != nil {
+			return args, err
+		}
+
+		args.values[arg.Name] = value
+	}
+
+	return args, nil
+}
+
+func (a Arg) parse(value string) (any, error) {
+	bitSize := map[ArgType]int{Int: strconv.IntSize, Int8: 8, Int16: 16}[a.Type]
+
+	if a.Type == String {
+		return value, nil
+	}
+
+	minValue, maxValue := a.Min, a.Max
+	if minValue == 0 && maxValue == 0 {
+		maxValue = int(math.MaxInt >> (strconv.IntSize - bitSize))
+		minValue = -maxValue - 1
+	}
+
+	number, err := strconv.ParseInt(value, 10, bitSize)
+
+	if err != nil || int(number) < minValue || int(number) > maxValue {
+		return nil, fmt.Errorf("invalid %s, expected a number between %d and %d", a.Name, minValue, maxValue)
+	}
+
+	switch a.Type {
+	case Int8:
+		return int8(number), nil
+	case Int16:
+		return int16(number), nil
+	default:
+		return int(number), nil
+	}
+}
+
+func (s Spec) formatArgs() string {
+	parts := []string{}
+
+	for _, arg := range s.Args {
+		if arg.Optional {
+			parts = append(parts, fmt.Sprintf("[%s?]", arg.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s]", arg.Name))
+		}
+	}
+
+	for _, flag := range s.Flags {
+		if flag.Value != "" {
+			parts = append(parts, fmt.Sprintf("[--%s %s?]", flag.Name, flag.Value))
+		} else {
+			parts = append(parts, fmt.Sprintf("[--%s?]", flag.Name))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (s Spec) Usage() string {
+	return strings.TrimSpace(s.Name + " " + s.formatArgs())
+}
+
+// Help returns the usage, aliases, arguments and flags of the command.
+func (r *Registry) Help(name string) (string, error) {
+	spec, ok := r.Find(name)
+
+	if !ok {
+		return "", ErrUnknownCommand
+	}
+
+	var sb strings.Builder
+	sb.WriteString(text.Bold.Sprint(spec.Usage()) + "\n")
+	sb.WriteString(spec.Desc + "\n")
+
+	if len(spec.Aliases) > 0 {
+		sb.WriteString("\nAliases: " + strings.Join(spec.Aliases, ", ") + "\n")
+	}
+
+	if len(spec.Args) > 0 || len(spec.Flags) > 0 {
+		t := table.NewWriter()
+		t.Style().Options.DrawBorder = false
+		t.Style().Options.SeparateColumns = false
+
+		for _, arg := range spec.Args {
+			details := []string{}
+
+			if arg.Type != String && (arg.Min != 0 || arg.Max != 0) {
+				details = append(details, fmt.Sprintf("%d-%d", arg.Min, arg.Max))
+			}
+
+			if arg.Optional {
+				details = append(details, "optional")
+			}
+
+			desc := arg.Desc
+			if len(details) > 0 {
+				desc = strings.TrimSpace(fmt.Sprintf("%s (%s)", desc, strings.Join(details, ", ")))
+			}
+
+			t.AppendRow(table.Row{text.Bold.Sprint(arg.Name), desc})
+		}
+
+		for _, flag := range spec.Flags {
+			t.AppendRow(table.Row{text.Bold.Sprint(strings.TrimSpace("--" + flag.Name + " " + flag.Value)), flag.Desc})
+		}
+
+		sb.WriteString("\n" + t.Render() + "\n")
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// Table returns all registered commands with their aliases, arguments and descriptions.
+func (r *Registry) Table() string {
+	t := table.NewWriter()
+	t.Style().Options.DrawBorder = false
+	t.Style().Options.SeparateColumns = false
+
+	for _, spec := range r.specs {
+
+		t.AppendRow(table.Row{text.Bold.Sprint(spec.Name),
+			strings.Join(spec.Aliases, ", "),
+			text.Bold.Sprint(spec.formatArgs()),
+			spec.Desc,
+		})
+	}
+
+	return t.Render()
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(received *Args) *Registry {
+	r := NewRegistry()
+	r.Register(Spec{
+		Name:    "a",
+		Aliases: []string{"add"},
+		Args: []Arg{
+			{Name: "name"},
+			{Name: "count", Type: Int8, Min: 1, Max: 10},
+			{Name: "minutes", Type: Int16, Optional: true},
+		},
+		Flags: []Flag{{Name: "json"}, {Name: "weeks", Value: "N"}},
+		Desc:  "Add",
+		Handler: func(args Args) error {
+			*received = args
+			return nil
+		},
+	})
+
+	return r
+}
+
+func TestRegistryExecute(t *testing.T) {
+
+	t.Run("parses typed arguments and flags", func(t *testing.T) {
+		var args Args
+		r := newTestRegistry(&args)
+		err := r.Execute(NewCommand("add test 3 --weeks 4 120 --json"))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		isParsed := args.String("name") == "test" &&
+			args.Int8("count") == 3 &&
+			args.Int16("minutes") == 120 &&
+			args.HasFlag("json") &&
+			args.Flag("weeks") == "4"
+
+		if !isParsed {
+			t.Errorf("invalid arguments: %+v", args)
+		}
+	})
+
+	t.Run("skips missing optional arguments", func(t *testing.T) {
+		var args Args
+		r := newTestRegistry(&args)
+		err := r.Execute(NewCommand("a test 3"))
+
+		if err != nil || args.Has("minutes") || args.HasFlag("json") {
+			t.Errorf("invalid arguments: %+v (%v)", args, err)
+		}
+	})
+
+	for _, input := range []string{"a test", "a test 11", "a test x", "a test 1 40000", "a test 1 2 3", "a test 1 --yaml", "a test 1 --weeks"} {
+
+		t.Run("returns a usage error for invalid arguments", func(t *testing.T) {
+			var args Args
+			r := newTestRegistry(&args)
+			err := r.Execute(NewCommand(input))
+
+			var usageErr UsageError
+			if !errors.As(err, &usageErr) || !strings.Contains(err.Error(), "usage: a [name] [count] [minutes?]") {
+				t.Errorf("expected a usage error for %q, got %v", input, err)
+			}
+		})
+	}
+
+	t.Run("returns an error for an unknown command", func(t *testing.T) {
+		var args Args
+		r := newTestRegistry(&args)
+		err := r.Execute(NewCommand("x"))
+
+		if !errors.Is(err, ErrUnknownCommand) {
+			t.Errorf("expected an unknown command error, got %v", err)
+		}
+	})
+}
+
+func TestRegistryHelp(t *testing.T) {
+
+	t.Run("describes a command", func(t *testing.T) {
+		var args Args
+		r := newTestRegistry(&args)
+		help, err := r.Help("add")
+
+		for _, want := range []string{"a [name] [count] [minutes?] [--json?] [--weeks N?]", "Aliases: add", "1-10", "optional", "--weeks N"} {
+			if err != nil || !strings.Contains(help, want) {
+				t.Errorf("expected the help to contain %q, got %s", want, help)
+			}
+		}
+	})
+
+	t.Run("lists all commands", func(t *testing.T) {
+		var args Args
+		r := newTestRegistry(&args)
+		table := r.Table()
+
+		if !strings.Contains(table, "Add") || !strings.Contains(table, "help") {
+			t.Errorf("expected the table to contain all commands, got %s", table)
+		}
+	})
+}
@@ -0,0 +1,853 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/seektor/habits-tracker-go/internal/command"
+	"github.com/seektor/habits-tracker-go/internal/importer"
+	"github.com/seektor/habits-tracker-go/internal/storage"
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+var habitArg = command.Arg{Name: "habit", Desc: "ID, name or unambiguous prefix of a name"}
+var habitsArg = command.Arg{Name: "habits", Desc: "comma separated habits, e.g. 1,3,read"}
+var stepsCountArg = command.Arg{Name: "stepsCount", Type: command.Int8, Min: 1, Max: math.MaxInt8}
+var stepMinutesArg = command.Arg{Name: "stepMinutes", Type: command.Int16, Min: 1, Max: int(MaxHabitTotalTime)}
+var formatArg = command.Arg{Name: "format", Desc: "csv or ics (iCalendar)"}
+var importFormatArg = command.Arg{Name: "format", Desc: "csv or loop (Loop Habit Tracker)"}
+var scheduleArg = command.Arg{Name: "schedule", Desc: "daily, mon,wed,fri, 3d (every 3 days) or 3/w (3 times per week)"}
+
+func (h *Habits) newCommands() *command.Registry {
+	r := command.NewRegistry()
+
+	r.Register(command.Spec{
+		Name:    "p",
+		Aliases: []string{"list"},
+		Args:    []command.Arg{optional(habitArg)},
+		Flags: []command.Flag{
+			{Name: "format", Value: "format", Desc: strings.Join(ListFormats, ", ") + ", table by default"},
+			{Name: "json", Desc: "print as JSON, same as --format=json"},
+		},
+		Desc:    "Print all habits / a habit",
+		Handler: h.printCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "heatmap",
+		Args:    []command.Arg{optional(habitArg)},
+		Flags:   []command.Flag{{Name: "weeks", Value: "N", Desc: fmt.Sprintf("number of weeks, %d by default", DefaultHeatmapWeeks)}},
+		Desc:    "Print a calendar heatmap of all habits / a habit",
+		Handler: h.heatmapCommand,
+	})
+	r.Register(command.Spec{
+		Name: "report",
+		Args: []command.Arg{
+			{Name: "period", Desc: strings.Join(ReportPeriods, ", ")},
+			{Name: "offset", Type: command.Int, Optional: true, Min: -1000, Max: 0, Desc: "0 for the current period by default, -1 for the previous one"},
+		},
+		Flags:   []command.Flag{{Name: "format", Value: "format", Desc: strings.Join(ReportFormats, ", ") + ", table by default"}},
+		Desc:    "Print the completion, minutes and streaks of the habits in a week, month or year",
+		Handler: h.reportCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "stats",
+		Args:    []command.Arg{habitArg},
+		Desc:    "Print the completion rates, weekdays, trend and strength of a habit",
+		Handler: h.statsCommand,
+	})
+	r.Register(command.Spec{
+		Name: "correlate",
+		Flags: []command.Flag{
+			{Name: "min-days", Value: "N", Desc: fmt.Sprintf("shared days of a listed pair, %d by default", DefaultCorrelationDays)},
+			{Name: "top", Value: "N", Desc: fmt.Sprintf("number of listed pairs, %d by default", DefaultCorrelationTop)},
+		},
+		Desc:    "Print the habits which are done together or apart the most",
+		Handler: h.correlateCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "a",
+		Aliases: []string{"add"},
+		Args:    []command.Arg{{Name: "name"}, stepsCountArg, stepMinutesArg, optional(scheduleArg)},
+		Desc:    "Add a habit",
+		Handler: h.addCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "d",
+		Aliases: []string{"delete"},
+		Args:    []command.Arg{habitArg},
+		Flags:   []command.Flag{{Name: "force", Desc: "do not ask for confirmation"}},
+		Desc:    "Delete a habit",
+		Handler: h.deleteCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "c",
+		Aliases: []string{"check"},
+		Args:    []command.Arg{habitsArg, {Name: "n", Type: command.Int8, Optional: true, Min: 1, Max: math.MaxInt8, Desc: "number of steps, 1 by default"}},
+		Desc:    "Check n steps of a habit / habits",
+		Handler: h.checkCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "u",
+		Aliases: []string{"uncheck"},
+		Args:    []command.Arg{habitsArg, {Name: "n", Type: command.Int8, Optional: true, Min: 1, Max: math.MaxInt8, Desc: "number of steps, 1 by default"}},
+		Desc:    "Uncheck n steps of a habit / habits",
+		Handler: h.uncheckCommand,
+	})
+	r.Register(command.Spec{
+		Name: "log",
+		Args: []command.Arg{
+			habitArg,
+			{Name: "date", Desc: "YYYY-MM-DD or -N for N days ago"},
+			{Name: "steps", Type: command.Int8, Min: 0, Max: math.MaxInt8, Desc: "number of checked steps"},
+		},
+		Desc:    "Set checked steps of a past day of a habit",
+		Handler: h.logCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "ct",
+		Aliases: []string{"time"},
+		Args:    []command.Arg{habitArg, stepMinutesArg},
+		Desc:    "Change step time in minutes of a habit",
+		Handler: h.changeStepMinutesCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "cs",
+		Aliases: []string{"steps"},
+		Args:    []command.Arg{habitArg, stepsCountArg},
+		Desc:    "Change number of steps",
+		Handler: h.changeStepsCountCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "sc",
+		Aliases: []string{"schedule"},
+		Args:    []command.Arg{habitArg, scheduleArg},
+		Desc:    "Change schedule of a habit",
+		Handler: h.changeScheduleCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "f",
+		Aliases: []string{"freeze"},
+		Args:    []command.Arg{optional(habitArg)},
+		Desc:    "Freeze all habits / a habit",
+		Handler: h.freezeCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "uf",
+		Aliases: []string{"unfreeze"},
+		Args:    []command.Arg{optional(habitArg)},
+		Desc:    "Unfreeze all habits / a habit",
+		Handler: h.unfreezeCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "tz",
+		Aliases: []string{"timezone"},
+		Args:    []command.Arg{{Name: "zone", Optional: true, Desc: "IANA name, e.g. Europe/Warsaw"}},
+		Desc:    "Print / change the time zone in which the days are counted",
+		Handler: h.timeZoneCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "ds",
+		Aliases: []string{"daystart"},
+		Args: []command.Arg{
+			{Name: "time", Optional: true, Desc: "HH:MM at which the days begin, default removes the setting of a habit"},
+			optional(habitArg),
+		},
+		Desc:    "Print / change the time at which the days begin, of all habits / a habit",
+		Handler: h.dayStartCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "export",
+		Args:    []command.Arg{formatArg, {Name: "file", Optional: true, Desc: "path of the file, printed when missing"}},
+		Desc:    "Export the daily history of all habits, and their schedules to a calendar",
+		Handler: h.exportCommand,
+	})
+	r.Register(command.Spec{
+		Name: "import",
+		Args: []command.Arg{importFormatArg, {Name: "file", Desc: "path of the file, or of the export directory"}},
+		Flags: []command.Flag{
+			{Name: "overwrite", Desc: "replace the days which differ from the tracked ones"},
+			{Name: "dry-run", Desc: "print what would be imported without saving it"},
+			{Name: "step-minutes", Value: "minutes", Desc: fmt.Sprintf("time of a step when the tracker does not track it, %d by default", importer.DefaultStepMinutes)},
+		},
+		Desc:    "Merge the daily history of habits from a file or another tracker",
+		Handler: h.importCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "undo",
+		Desc:    fmt.Sprintf("Undo the last change, up to %d changes", UndoHistoryLen),
+		Handler: func(args command.Args) error { return h.travel(true) },
+	})
+	r.Register(command.Spec{
+		Name:    "redo",
+		Desc:    "Redo the last undone change",
+		Handler: func(args command.Args) error { return h.travel(false) },
+	})
+	r.Register(command.Spec{
+		Name:    "rebuild",
+		Desc:    "Recompute habits from the event log",
+		Handler: h.rebuildCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "restore",
+		Args:    []command.Arg{{Name: "backup", Type: command.Int, Optional: true, Min: 1, Max: storage.DefaultBackupsCount, Desc: "number of the backup"}},
+		Desc:    "List backups / restore habits from a backup",
+		Handler: h.restoreCommand,
+	})
+	r.Register(command.Spec{
+		Name:    "q",
+		Aliases: []string{"quit"},
+		Desc:    "Quit",
+		Handler: func(args command.Args) error { return command.ErrQuit },
+	})
+
+	return r
+}
+
+func optional(arg command.Arg) command.Arg {
+	arg.Optional = true
+	return arg
+}
+
+// Execute runs the command and reports its outcome.
+// Invalid input is reported by a command.UsageError, the Quit command by command.ErrQuit.
+func (h *Habits) Execute(cmd command.Command) error {
+	h.running = cmd.String()
+	return h.commands.Execute(cmd)
+}
+
+func (h *Habits) PrintCommands() {
+	fmt.Println(h.commands.Table())
+}
+
+func (h *Habits) printCommand(args command.Args) error {
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	habits := h.Habits
+
+	if args.Has("habit") {
+		habit, err := h.Get(args.String("habit"))
+
+		if err != nil {
+			return err
+		}
+
+		habits = []Habit{*habit}
+	}
+
+	format := "table"
+
+	switch {
+	case args.HasFlag("json"):
+		format = "json"
+	case args.HasFlag("format"):
+		format = args.Flag("format")
+	}
+
+	if err := checkFormat(format, ListFormats...); err != nil {
+		return err
+	}
+
+	return h.WriteFormat(os.Stdout, format, habits...)
+}
+
+func (h *Habits) heatmapCommand(args command.Args) error {
+	weeks, err := getIntFlag(args, "weeks", DefaultHeatmapWeeks, 1, MaxHeatmapWeeks)
+
+	if err != nil {
+		return err
+	}
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	title, habits := "All habits", h.Habits
+
+	if args.Has("habit") {
+		habit, err := h.Get(args.String("habit"))
+
+		if err != nil {
+			return err
+		}
+
+		title, habits = habit.Name, []Habit{*habit}
+	}
+
+	fmt.Println(h.renderHeatmap(title, habits, weeks))
+
+	return nil
+}
+
+func (h *Habits) reportCommand(args command.Args) error {
+	period, format := args.String("period"), "table"
+
+	if !slices.Contains(ReportPeriods, period) {
+		return command.NewUsageError(fmt.Sprintf("unknown period %q, expected %s", period, strings.Join(ReportPeriods, " or ")))
+	}
+
+	if args.HasFlag("format") {
+		format = args.Flag("format")
+	}
+
+	if err := checkFormat(format, ReportFormats...); err != nil {
+		return err
+	}
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	return h.WriteReport(os.Stdout, period, args.Int("offset"), format)
+}
+
+func (h *Habits) statsCommand(args command.Args) error {
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	habit, err := h.Get(args.String("habit"))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(h.renderStats(*habit))
+
+	return nil
+}
+
+func (h *Habits) correlateCommand(args command.Args) error {
+	minDays, err := getIntFlag(args, "min-days", DefaultCorrelationDays, 2, math.MaxInt16)
+
+	if err != nil {
+		return err
+	}
+
+	top, err := getIntFlag(args, "top", DefaultCorrelationTop, 1, 100)
+
+	if err != nil {
+		return err
+	}
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	fmt.Println(h.renderCorrelations(minDays, top))
+
+	return nil
+}
+
+func (h *Habits) addCommand(args command.Args) error {
+	schedule, err := ParseSchedule(args.String("schedule"), h.today(nil))
+
+	if err != nil {
+		return command.NewUsageError(err.Error())
+	}
+
+	return h.mutate("Habit has been created", func() error {
+		return h.emit(Event{
+			Type:        EventCreated,
+			Name:        args.String("name"),
+			StepsCount:  args.Int8("stepsCount"),
+			StepMinutes: args.Int16("stepMinutes"),
+			Schedule:    &schedule,
+		})
+	})
+}
+
+func (h *Habits) deleteCommand(args command.Args) error {
+	if h.Confirm != nil && !args.HasFlag("force") {
+		if err := h.sync(); err != nil {
+			return err
+		}
+
+		habit, err := h.Get(args.String("habit"))
+
+		if err != nil {
+			return err
+		}
+
+		if !h.Confirm(fmt.Sprintf("Delete %s with its whole history?", habit.Name)) {
+			return errors.New("habit has not been deleted")
+		}
+	}
+
+	return h.mutate("Habit has been deleted", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventDeleted})
+	})
+}
+
+func (h *Habits) checkCommand(args command.Args) error {
+	return h.changeCheckedSteps(args, EventChecked)
+}
+
+func (h *Habits) uncheckCommand(args command.Args) error {
+	return h.changeCheckedSteps(args, EventUnchecked)
+}
+
+func (h *Habits) changeCheckedSteps(args command.Args, eventType EventType) error {
+	steps := int8(1)
+	if args.Has("n") {
+		steps = args.Int8("n")
+	}
+
+	return h.mutate("Steps have been updated", func() error {
+		selected, err := h.getMany(args.String("habits"))
+
+		if err != nil {
+			return err
+		}
+
+		for _, habit := range selected {
+			if habit.IsFrozen {
+				return fmt.Errorf("%s is frozen", habit.Name)
+			}
+		}
+
+		ids := []int{}
+		for _, habit := range selected {
+			ids = append(ids, habit.ID)
+		}
+
+		for _, id := range ids {
+			if err := h.emit(Event{Type: eventType, Habit: id, Steps: steps}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// parseDay reads a date as YYYY-MM-DD or as -N days before today.
+func parseDay(value string, today time.Time) (time.Time, error) {
+	if days, ok := strings.CutPrefix(value, "-"); ok {
+		n, err := strconv.Atoi(days)
+
+		if err != nil || n < 1 {
+			return time.Time{}, fmt.Errorf("invalid date %q, expected -N with N of at least 1", value)
+		}
+
+		return today.AddDate(0, 0, -n), nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or -N", value)
+	}
+
+	return date, nil
+}
+
+func (h *Habits) logCommand(args command.Args) error {
+	if _, err := parseDay(args.String("date"), h.today(nil)); err != nil {
+		return command.NewUsageError(err.Error())
+	}
+
+	return h.mutate("Day has been logged", func() error {
+		habit, err := h.Get(args.String("habit"))
+
+		if err != nil {
+			return err
+		}
+
+		today := h.today(habit)
+		date, _ := parseDay(args.String("date"), today)
+
+		if !date.Before(today) {
+			return errors.New("only past days can be logged, use check for today")
+		}
+
+		return h.emit(Event{Type: EventDayLogged, Habit: habit.ID, Date: &date, Steps: args.Int8("steps")})
+	})
+}
+
+func (h *Habits) changeStepMinutesCommand(args command.Args) error {
+	return h.mutate("Step time has been updated", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventStepMinutesChanged, StepMinutes: args.Int16("stepMinutes")})
+	})
+}
+
+func (h *Habits) changeStepsCountCommand(args command.Args) error {
+	return h.mutate("Steps count has been updated", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventStepsCountChanged, StepsCount: args.Int8("stepsCount")})
+	})
+}
+
+func (h *Habits) changeScheduleCommand(args command.Args) error {
+	schedule, err := ParseSchedule(args.String("schedule"), h.today(nil))
+
+	if err != nil {
+		return command.NewUsageError(err.Error())
+	}
+
+	return h.mutate("Schedule has been updated", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventScheduleChanged, Schedule: &schedule})
+	})
+}
+
+func (h *Habits) freezeCommand(args command.Args) error {
+	if !args.Has("habit") {
+		return h.mutate("Habits have been frozen", func() error {
+			return h.emit(Event{Type: EventFrozen})
+		})
+	}
+
+	return h.mutate("Habit has been frozen", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventFrozen})
+	})
+}
+
+func (h *Habits) unfreezeCommand(args command.Args) error {
+	if !args.Has("habit") {
+		return h.mutate("Habits have been unfrozen", func() error {
+			return h.emit(Event{Type: EventUnfrozen})
+		})
+	}
+
+	return h.mutate("Habit has been unfrozen", func() error {
+		return h.emitForHabit(args.String("habit"), Event{Type: EventUnfrozen})
+	})
+}
+
+func (h *Habits) timeZoneCommand(args command.Args) error {
+	if !args.Has("zone") {
+		if err := h.sync(); err != nil {
+			return err
+		}
+
+		utils.PrintlnInfo(fmt.Sprintf("Days are counted in %s, it is %s there", h.TimeZone, h.now().Format(time.DateTime)))
+		return nil
+	}
+
+	if _, err := parseTimeZone(args.String("zone")); err != nil {
+		return command.NewUsageError(err.Error())
+	}
+
+	return h.mutate("Time zone has been changed", func() error {
+		return h.emit(Event{Type: EventTimeZoneChanged, TimeZone: args.String("zone")})
+	})
+}
+
+func (h *Habits) dayStartCommand(args command.Args) error {
+	if !args.Has("time") {
+		if err := h.sync(); err != nil {
+			return err
+		}
+
+		utils.PrintlnInfo(fmt.Sprintf("Days begin at %s", h.DayStartsAt))
+		for _, habit := range h.Habits {
+			if habit.DayStartsAt != nil {
+				utils.PrintlnInfo(fmt.Sprintf("Days of %s begin at %s", habit.Name, habit.DayStartsAt))
+			}
+		}
+
+		return nil
+	}
+
+	isDefault := args.String("time") == "default"
+
+	if isDefault && !args.Has("habit") {
+		return command.NewUsageError("default can be used only for a habit")
+	}
+
+	dayStart, err := ParseDayStart(args.String("time"))
+
+	if err != nil && !isDefault {
+		return command.NewUsageError(err.Error())
+	}
+
+	if !args.Has("habit") {
+		return h.mutate("Day start has been changed", func() error {
+			return h.emit(Event{Type: EventDayStartChanged, DayStart: &dayStart})
+		})
+	}
+
+	e := Event{Type: EventDayStartChanged, DayStart: &dayStart}
+	if isDefault {
+		e.DayStart = nil
+	}
+
+	return h.mutate("Day start of the habit has been changed", func() error {
+		return h.emitForHabit(args.String("habit"), e)
+	})
+}
+
+func (h *Habits) restoreCommand(args command.Args) error {
+	backups, err := storage.ListBackups(h.path)
+
+	if err != nil {
+		return err
+	}
+
+	if !args.Has("backup") {
+		if len(backups) == 0 {
+			utils.PrintlnInfo("There are no backups")
+			return nil
+		}
+
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"#", "Created At"})
+
+		for idx, backup := range backups {
+			t.AppendRow(table.Row{idx + 1, backup.CreatedAt.In(h.location).Format(time.DateTime)})
+		}
+
+		fmt.Println(t.Render())
+		return nil
+	}
+
+	if args.Int("backup") > len(backups) {
+		return fmt.Errorf("backup %d does not exist", args.Int("backup"))
+	}
+
+	backup := backups[args.Int("backup")-1]
+	createdAt := backup.CreatedAt.In(h.location).Format(time.DateTime)
+
+	return h.mutate(fmt.Sprintf("Habits have been restored from %s", createdAt), func() error {
+		state, err := readBackup(backup.Path)
+
+		if err != nil {
+			return err
+		}
+
+		if err := h.emit(Event{Type: EventSnapshot, State: state}); err != nil {
+			return err
+		}
+
+		h.UpdateToPresent()
+
+		return nil
+	})
+}
+
+func (h *Habits) rebuildCommand(args command.Args) error {
+	if h.path == "" {
+		return errors.New("data file has not been set")
+	}
+
+	return h.withLock(func() error {
+		if err := h.sync(); err != nil {
+			return err
+		}
+
+		count, err := h.rebuild()
+
+		if err != nil {
+			return err
+		}
+
+		h.UpdateToPresent()
+
+		if err := h.Save(h.path); err != nil {
+			return fmt.Errorf("changes have not been saved: %w", err)
+		}
+
+		utils.PrintlnSuccess(fmt.Sprintf("Habits have been rebuilt from %d events", count))
+
+		return nil
+	})
+}
+
+// getIntFlag returns the value of the numeric flag, or the default value when the flag is missing.
+func getIntFlag(args command.Args, name string, defaultValue int, min int, max int) (int, error) {
+	if !args.HasFlag(name) {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(args.Flag(name))
+
+	if err != nil || value < min || value > max {
+		return 0, command.NewUsageError(fmt.Sprintf("invalid --%s %q, expected a number from %d to %d", name, args.Flag(name), min, max))
+	}
+
+	return value, nil
+}
+
+func checkFormat(format string, formats ...string) error {
+	if !slices.Contains(formats, format) {
+		return command.NewUsageError(fmt.Sprintf("unknown format %q, expected %s", format, strings.Join(formats, " or ")))
+	}
+
+	return nil
+}
+
+func (h *Habits) exportCommand(args command.Args) error {
+	if err := checkFormat(args.String("format"), "csv", "ics"); err != nil {
+		return err
+	}
+
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	write := h.WriteCSV
+
+	if args.String("format") == "ics" {
+		write = h.WriteICS
+	}
+
+	if err := write(&buf, h.Habits...); err != nil {
+		return err
+	}
+
+	if !args.Has("file") {
+		fmt.Print(buf.String())
+		return nil
+	}
+
+	if err := storage.WriteFileAtomic(args.String("file"), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	utils.PrintlnSuccess(fmt.Sprintf("Habits have been exported to %s", args.String("file")))
+
+	return nil
+}
+
+// readImport reads the habits of the file in the format, csv is the own format and the others are read by the importers.
+func readImport(format string, path string, stepMinutes int16) ([]importer.Habit, error) {
+	if format != "csv" {
+		imp, err := importer.Get(format)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return imp.Read(path, importer.Options{StepMinutes: stepMinutes})
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return readCSV(file)
+}
+
+func (h *Habits) importCommand(args command.Args) error {
+	if err := checkFormat(args.String("format"), append([]string{"csv"}, importer.Names()...)...); err != nil {
+		return err
+	}
+
+	stepMinutes, err := getIntFlag(args, "step-minutes", 0, 1, int(MaxHabitTotalTime))
+
+	if err != nil {
+		return err
+	}
+
+	imported, err := readImport(args.String("format"), args.String("file"), int16(stepMinutes))
+
+	if err != nil {
+		return fmt.Errorf("%s cannot be imported: %w", args.String("file"), err)
+	}
+
+	if args.HasFlag("dry-run") {
+		return h.previewImport(imported, args.HasFlag("overwrite"))
+	}
+
+	var result importResult
+
+	err = h.mutate("Habits have been imported", func() error {
+		result, err = h.importHabits(imported, args.HasFlag("overwrite"))
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range result.Created {
+		utils.PrintlnInfo(fmt.Sprintf("%s has been created", name))
+	}
+
+	printImportResult(result, args.HasFlag("overwrite"))
+
+	return nil
+}
+
+// previewImport imports the habits into a copy of the habits and prints the habits which would be created or changed.
+func (h *Habits) previewImport(imported []importer.Habit, overwrite bool) error {
+	if err := h.sync(); err != nil {
+		return err
+	}
+
+	state, err := json.Marshal(h)
+
+	if err != nil {
+		return err
+	}
+
+	preview := NewHabits(h.clock)
+
+	if err := preview.decode(state); err != nil {
+		return err
+	}
+
+	result, err := preview.importHabits(imported, overwrite)
+
+	if err != nil {
+		return err
+	}
+
+	utils.PrintlnInfo("Dry run, nothing has been saved")
+
+	changed := []Habit{}
+
+	for _, habit := range preview.Habits {
+		idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.ID == habit.ID })
+
+		if idx < 0 || !slices.EqualFunc(h.Habits[idx].Summary.History, habit.Summary.History, func(a, b Entry) bool {
+			return a.Date.Equal(b.Date) && isSameDay(a, b)
+		}) {
+			changed = append(changed, habit)
+		}
+	}
+
+	if len(changed) > 0 {
+		preview.Print(changed...)
+	}
+
+	for _, name := range result.Created {
+		utils.PrintlnInfo(fmt.Sprintf("%s would be created", name))
+	}
+
+	printImportResult(result, overwrite)
+
+	return nil
+}
+
+func printImportResult(result importResult, overwrite bool) {
+	for _, warning := range result.Warnings {
+		utils.PrintlnError(warning)
+	}
+
+	utils.PrintlnInfo(fmt.Sprintf("Days added: %d, replaced: %d, unchanged: %d, conflicts: %d", result.Added, result.Replaced, result.Unchanged, len(result.Conflicts)))
+
+	for _, conflict := range result.Conflicts {
+		utils.PrintlnError(conflict)
+	}
+
+	if len(result.Conflicts) > 0 && !overwrite {
+		utils.PrintlnInfo("Run the import with --overwrite to replace the conflicting days")
+	}
+}
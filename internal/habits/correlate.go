@@ -0,0 +1,161 @@
+package habits
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const DefaultCorrelationDays = 14 // shared days below which a pair is not listed
+const DefaultCorrelationTop = 5
+
+// habitPair counts the shared days of two habits by whether each of them was done.
+type habitPair struct {
+	First      Habit
+	Second     Habit
+	Both       int
+	FirstOnly  int
+	SecondOnly int
+	None       int
+}
+
+func (p habitPair) Days() int {
+	return p.Both + p.FirstOnly + p.SecondOnly + p.None
+}
+
+// Correlation is the phi coefficient of the two habits, from -1 to 1. It is NaN when one of them
+// was always or never done, since then it tells nothing about the other one.
+func (p habitPair) Correlation() float64 {
+	firstDone, secondDone := p.Both+p.FirstOnly, p.Both+p.SecondOnly
+	firstNot, secondNot := p.SecondOnly+p.None, p.FirstOnly+p.None
+	denominator := math.Sqrt(float64(firstDone) * float64(firstNot) * float64(secondDone) * float64(secondNot))
+
+	if denominator == 0 {
+		return math.NaN()
+	}
+
+	return (float64(p.Both)*float64(p.None) - float64(p.FirstOnly)*float64(p.SecondOnly)) / denominator
+}
+
+// stringifyConditional returns the share of the days on which the habit was done among the given ones.
+func stringifyConditional(done int, days int) string {
+	if days == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%% (%d/%d)", float64(done)/float64(days)*100, done, days)
+}
+
+// getDoneDays returns whether the habit was done on its closed days. Frozen days and off days
+// without checked steps are skipped, a day of a ScheduleTimesPerWeek habit counts as not done until it is checked.
+func getDoneDays(history []Entry) map[time.Time]bool {
+	days := map[time.Time]bool{}
+
+	for _, entry := range history {
+		if entry.IsFrozen || entry.IsOffDay && entry.CheckedSteps == 0 {
+			continue
+		}
+
+		days[entry.Date] = entry.IsFulfilled()
+	}
+
+	return days
+}
+
+func getHabitPair(first Habit, second Habit) habitPair {
+	pair := habitPair{First: first, Second: second}
+	secondDays := getDoneDays(second.Summary.History)
+
+	for date, firstDone := range getDoneDays(first.Summary.History) {
+		secondDone, ok := secondDays[date]
+
+		switch {
+		case !ok:
+			continue
+		case firstDone && secondDone:
+			pair.Both += 1
+		case firstDone:
+			pair.FirstOnly += 1
+		case secondDone:
+			pair.SecondOnly += 1
+		default:
+			pair.None += 1
+		}
+	}
+
+	return pair
+}
+
+// getCorrelations returns the pairs of habits with at least minDays shared days and a defined correlation,
+// the positive and the negative ones, each from the strongest.
+func (h *Habits) getCorrelations(minDays int) ([]habitPair, []habitPair) {
+	positive, negative := []habitPair{}, []habitPair{}
+
+	for idx, first := range h.Habits {
+		for _, second := range h.Habits[idx+1:] {
+			pair := getHabitPair(first, second)
+			correlation := pair.Correlation()
+
+			switch {
+			case pair.Days() < minDays || math.IsNaN(correlation):
+				continue
+			case correlation > 0:
+				positive = append(positive, pair)
+			case correlation < 0:
+				negative = append(negative, pair)
+			}
+		}
+	}
+
+	slices.SortStableFunc(positive, func(a, b habitPair) int { return cmp.Compare(b.Correlation(), a.Correlation()) })
+	slices.SortStableFunc(negative, func(a, b habitPair) int { return cmp.Compare(a.Correlation(), b.Correlation()) })
+
+	return positive, negative
+}
+
+func renderPairs(title string, pairs []habitPair, top int) string {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetTitle(title)
+	t.AppendHeader(table.Row{"Habit A", "Habit B", "Correlation", "Days", "B when A", "A when B"})
+
+	for _, pair := range pairs[:min(top, len(pairs))] {
+		t.AppendRow(table.Row{
+			pair.First.Name,
+			pair.Second.Name,
+			fmt.Sprintf("%+.2f", pair.Correlation()),
+			pair.Days(),
+			stringifyConditional(pair.Both, pair.Both+pair.FirstOnly),
+			stringifyConditional(pair.Both, pair.Both+pair.SecondOnly),
+		})
+	}
+
+	return t.Render()
+}
+
+// renderCorrelations draws the strongest positive and negative relationships between the habits
+// over the closed days they share.
+func (h *Habits) renderCorrelations(minDays int, top int) string {
+	positive, negative := h.getCorrelations(minDays)
+
+	if len(positive)+len(negative) == 0 {
+		return fmt.Sprintf("No pair of habits shares %d days with a correlation.", minDays)
+	}
+
+	sections := []string{}
+
+	if len(positive) > 0 {
+		sections = append(sections, renderPairs("Done together", positive, top))
+	}
+
+	if len(negative) > 0 {
+		sections = append(sections, renderPairs("Done apart", negative, top))
+	}
+
+	return strings.Join(sections, "\n")
+}
@@ -0,0 +1,116 @@
+package habits
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+// newCorrelationHabits tracks Gym, Read, TV and Walk, a habit of 1 step per history of days, see habitSpec.
+func newCorrelationHabits(t *testing.T, histories ...string) *Habits {
+	specs := []habitSpec{}
+
+	for idx, history := range histories {
+		specs = append(specs, habitSpec{name: []string{"Gym", "Read", "TV", "Walk"}[idx], stepsCount: 1, stepMinutes: 30, history: history})
+	}
+
+	return newHabitsWith(t, specs...)
+}
+
+func TestGetHabitPair(t *testing.T) {
+	var tests = []struct {
+		name   string
+		first  string
+		second string
+		want   [4]int
+	}{
+		{"counts the shared days", "11010", "10100", [4]int{1, 2, 1, 1}},
+		{"skips the frozen days of either habit", "1f10", "10f1", [4]int{1, 0, 1, 0}},
+		{"skips the off days", "1o01", "111o", [4]int{1, 0, 1, 0}},
+		{"skips the days which are not shared", "10101", "00", [4]int{0, 1, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habits := newCorrelationHabits(t, tt.first, tt.second)
+			pair := getHabitPair(habits.Habits[0], habits.Habits[1])
+
+			if got := [4]int{pair.Both, pair.FirstOnly, pair.SecondOnly, pair.None}; got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHabitPairCorrelation(t *testing.T) {
+	var tests = []struct {
+		name string
+		pair habitPair
+		want float64
+	}{
+		{"is 1 for habits done on the same days", habitPair{Both: 3, None: 2}, 1},
+		{"is -1 for habits done on different days", habitPair{FirstOnly: 3, SecondOnly: 2}, -1},
+		{"is 0 for independent habits", habitPair{Both: 1, FirstOnly: 1, SecondOnly: 1, None: 1}, 0},
+		{"is the phi coefficient", habitPair{Both: 10, SecondOnly: 1, None: 9}, 90 / math.Sqrt(10*9*11*10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pair.Correlation(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("is undefined for a habit which was always done", func(t *testing.T) {
+		if got := (habitPair{Both: 2, FirstOnly: 3}).Correlation(); !math.IsNaN(got) {
+			t.Errorf("expected NaN, got %v", got)
+		}
+	})
+}
+
+func TestGetCorrelations(t *testing.T) {
+	habits := newCorrelationHabits(t,
+		"1010101010",
+		"1110101010",
+		"0101010101",
+		"1111111111",
+	)
+
+	t.Run("sorts the pairs from the strongest", func(t *testing.T) {
+		positive, negative := habits.getCorrelations(DefaultCorrelationDays / 2)
+
+		if len(positive) != 1 || positive[0].First.Name != "Gym" || positive[0].Second.Name != "Read" {
+			t.Errorf("expected Gym and Read to be done together, got %+v", positive)
+		}
+
+		if len(negative) != 2 || negative[0].Second.Name != "TV" || negative[0].Correlation() != -1 || negative[1].First.Name != "Read" {
+			t.Errorf("expected Gym and TV, then Read and TV to be done apart, got %+v", negative)
+		}
+	})
+
+	t.Run("skips the pairs with too few shared days", func(t *testing.T) {
+		if positive, negative := habits.getCorrelations(11); len(positive)+len(negative) != 0 {
+			t.Errorf("expected no pairs, got %+v, %+v", positive, negative)
+		}
+	})
+
+	t.Run("renders the conditional completion", func(t *testing.T) {
+		output := habits.renderCorrelations(10, 1)
+
+		expected := "│ Gym     │ Read    │ +0.82       │   10 │ 100% (5/5) │ 83% (5/6) │"
+		if !strings.Contains(output, expected) || strings.Contains(output, "│ Read    │ TV") {
+			t.Errorf("expected only the strongest pair of each kind, got\n%s", output)
+		}
+	})
+
+	t.Run("rejects an invalid number of days", func(t *testing.T) {
+		err := habits.Execute(command.NewCommand("correlate --min-days 1"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
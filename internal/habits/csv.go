@@ -0,0 +1,177 @@
+package habits
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/importer"
+)
+
+var csvHeader = []string{"date", "habit", "goal_steps", "checked_steps", "minutes", "frozen"}
+
+func newCSVRow(habit Habit, entry Entry) []string {
+	return []string{
+		entry.Date.Format(time.DateOnly),
+		habit.Name,
+		strconv.Itoa(int(entry.StepsCount)),
+		strconv.Itoa(int(entry.CheckedSteps)),
+		strconv.Itoa(entry.Minutes()),
+		strconv.FormatBool(entry.IsFrozen),
+	}
+}
+
+// WriteCSV writes a row per habit per closed day, the current day is not closed yet and is skipped.
+func (h *Habits) WriteCSV(w io.Writer, habits ...Habit) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, habit := range habits {
+		for _, entry := range habit.Summary.History {
+			if err := writer.Write(newCSVRow(habit, entry)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// readCSV reads the rows written by WriteCSV, the columns are matched by the header.
+// A habit which is not tracked yet gets the goal and the step time of its latest day.
+func readCSV(r io.Reader) ([]importer.Habit, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for idx, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q, expected %s", name, strings.Join(csvHeader, ","))
+		}
+	}
+
+	habits := []importer.Habit{}
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		name, day, err := parseCSVRow(record, columns)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		day.Source = fmt.Sprintf("line %d", line)
+		idx := slices.IndexFunc(habits, func(habit importer.Habit) bool { return strings.EqualFold(habit.Name, name) })
+
+		if idx < 0 {
+			habits = append(habits, importer.Habit{Name: name, StepsCount: 1, Schedule: "daily"})
+			idx = len(habits) - 1
+		}
+
+		habits[idx].Days = append(habits[idx].Days, day)
+	}
+
+	for idx := range habits {
+		habit := &habits[idx]
+		slices.SortStableFunc(habit.Days, func(a, b importer.Day) int { return a.Date.Compare(b.Date) })
+
+		for _, day := range habit.Days {
+			if day.IsFrozen {
+				continue
+			}
+
+			habit.StepsCount = day.StepsCount
+			if day.CheckedSteps > 0 {
+				habit.StepMinutes, _ = getStepMinutes(day.Minutes, day.CheckedSteps, day.StepsCount)
+			}
+		}
+	}
+
+	return habits, nil
+}
+
+func parseCSVRow(record []string, columns map[string]int) (string, importer.Day, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(record[columns[name]])
+	}
+
+	name, day := field("habit"), importer.Day{}
+	var err error
+
+	if day.Date, err = time.Parse(time.DateOnly, field("date")); err != nil {
+		return name, day, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", field("date"))
+	}
+
+	if name == "" {
+		return name, day, errors.New("missing habit")
+	}
+
+	goalSteps, err := strconv.ParseInt(field("goal_steps"), 10, 8)
+
+	if err != nil || goalSteps < 0 {
+		return name, day, fmt.Errorf("invalid goal_steps %q", field("goal_steps"))
+	}
+
+	checkedSteps, err := strconv.ParseInt(field("checked_steps"), 10, 8)
+
+	if err != nil || checkedSteps < 0 {
+		return name, day, fmt.Errorf("invalid checked_steps %q", field("checked_steps"))
+	}
+
+	minutes, err := strconv.Atoi(field("minutes"))
+
+	if err != nil || minutes < 0 || minutes > math.MaxInt16 {
+		return name, day, fmt.Errorf("invalid minutes %q", field("minutes"))
+	}
+
+	if day.IsFrozen, err = strconv.ParseBool(field("frozen")); err != nil {
+		return name, day, fmt.Errorf("invalid frozen %q, expected true or false", field("frozen"))
+	}
+
+	if goalSteps == 0 && !day.IsFrozen {
+		return name, day, errors.New("goal_steps of a day which is not frozen has to be positive")
+	}
+
+	day.StepsCount, day.CheckedSteps, day.Minutes = int8(goalSteps), int8(checkedSteps), minutes
+
+	if _, ok := getStepMinutes(minutes, day.CheckedSteps, day.StepsCount); checkedSteps > 0 && !ok {
+		return name, day, fmt.Errorf("minutes %d do not fit %d checked steps, expected 1 to %d minutes per step",
+			minutes, checkedSteps, MaxHabitTotalTime/int16(max(1, goalSteps)))
+	}
+
+	return name, day, nil
+}
@@ -0,0 +1,183 @@
+package habits
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+// newCSVHabits tracks a habit for two closed days, checked and frozen, and an open day.
+func newCSVHabits(t *testing.T) (*Habits, *clock.Fake) {
+	fake := clock.NewFake(day.Add(12 * time.Hour))
+	habits := newHabitsAt(fake)
+
+	if err := habits.Load(filepath.Join(t.TempDir(), "habits.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	habits.Execute(command.NewCommand("a Read 2 30"))
+	habits.Execute(command.NewCommand("c read 2"))
+	fake.AdvanceDays(1)
+	habits.Execute(command.NewCommand("f read"))
+	fake.AdvanceDays(1)
+	habits.Execute(command.NewCommand("uf read"))
+	habits.Execute(command.NewCommand("c read"))
+
+	return habits, fake
+}
+
+func writeTestFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "import.csv")
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestWriteCSV(t *testing.T) {
+	habits, _ := newCSVHabits(t)
+	var buf bytes.Buffer
+
+	if err := habits.WriteCSV(&buf, habits.Habits...); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "date,habit,goal_steps,checked_steps,minutes,frozen\n" +
+		"2020-11-20,Read,2,2,60,false\n" +
+		"2020-11-21,Read,0,0,0,true\n"
+
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"accepts the columns in any order", "habit,date,frozen,minutes,checked_steps,goal_steps\nRead,2020-11-20,false,30,1,2\n", ""},
+		{"rejects a missing column", "date,habit,goal_steps,checked_steps,minutes\n", `missing column "frozen"`},
+		{"rejects an invalid date", "date,habit,goal_steps,checked_steps,minutes,frozen\n20.11.2020,Read,2,1,30,false\n", "line 2: invalid date"},
+		{"rejects a negative number", "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-20,Read,2,-1,30,false\n", "line 2: invalid checked_steps"},
+		{"rejects fewer minutes than checked steps", "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-20,Read,2,2,1,false\n", "line 2: minutes 1 do not fit 2 checked steps"},
+		{"rejects a step time above the limit", "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-20,Read,2,1,600,false\n", "expected 1 to 480 minutes per step"},
+		{"rejects a day without a goal", "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-20,Read,0,0,0,false\n", "line 2: goal_steps"},
+		{"rejects an empty file", "", "file is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readCSV(strings.NewReader(tt.content))
+
+			if tt.err == "" && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+
+	t.Run("imports an exported file into empty habits", func(t *testing.T) {
+		habits, fake := newCSVHabits(t)
+		exported := filepath.Join(t.TempDir(), "export.csv")
+		habits.Execute(command.NewCommand("export csv " + exported))
+
+		imported := newHabitsAt(fake)
+
+		if err := imported.Load(filepath.Join(t.TempDir(), "habits.json")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := imported.Execute(command.NewCommand("import csv " + exported)); err != nil {
+			t.Fatal(err)
+		}
+
+		habit := imported.Habits[0]
+		expected := habits.Habits[0].Summary
+
+		if habit.Name != "Read" || habit.StepsCount != 2 || habit.StepMinutes != 30 || len(habit.Summary.History) != 2 {
+			t.Fatalf("expected the habit to be created with its history, got %+v", habit)
+		}
+
+		if habit.Summary.TotalTime != expected.TotalTime || habit.Summary.CurrentStreak != expected.CurrentStreak || !habit.Summary.History[1].IsFrozen {
+			t.Errorf("expected the summary %+v, got %+v", expected, habit.Summary)
+		}
+	})
+
+	t.Run("skips the unchanged days and reports the conflicts", func(t *testing.T) {
+		habits, _ := newCSVHabits(t)
+		imported, _ := readCSV(strings.NewReader("date,habit,goal_steps,checked_steps,minutes,frozen\n" +
+			"2020-11-19,read,2,1,30,false\n" +
+			"2020-11-20,Read,2,2,60,false\n" +
+			"2020-11-21,Read,2,2,60,false\n" +
+			"2020-11-22,Read,2,1,30,false\n"))
+
+		var result importResult
+
+		habits.mutate("", func() (err error) {
+			result, err = habits.importHabits(imported, false)
+			return err
+		})
+
+		if result.Added != 1 || result.Unchanged != 1 || result.Replaced != 0 || len(result.Conflicts) != 2 {
+			t.Errorf("expected 1 added, 1 unchanged and 2 conflicts, got %+v", result)
+		}
+
+		history := habits.Habits[0].Summary.History
+		if len(history) != 3 || !history[0].Date.Equal(dayAfter(-1)) || !history[2].IsFrozen {
+			t.Errorf("expected the missing day to be added and the conflict to be kept, got %v", history)
+		}
+	})
+
+	t.Run("replaces the conflicting days", func(t *testing.T) {
+		habits, _ := newCSVHabits(t)
+		path := writeTestFile(t, "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-21,Read,2,2,60,false\n")
+
+		if err := habits.Execute(command.NewCommand("import csv " + path + " --overwrite")); err != nil {
+			t.Fatal(err)
+		}
+
+		summary := habits.Habits[0].Summary
+		if summary.History[1].IsFrozen || summary.CurrentStreak != 2 {
+			t.Errorf("expected the frozen day to be replaced, got %+v", summary)
+		}
+	})
+
+	t.Run("can be rebuilt and undone", func(t *testing.T) {
+		habits, _ := newCSVHabits(t)
+		path := writeTestFile(t, "date,habit,goal_steps,checked_steps,minutes,frozen\n2020-11-01,Write,1,1,15,false\n")
+		habits.Execute(command.NewCommand("import csv " + path))
+
+		if err := habits.Execute(command.NewCommand("rebuild")); err != nil || len(habits.Habits) != 2 || len(habits.Habits[1].Summary.History) != 1 {
+			t.Fatalf("expected the import to be rebuilt, got %+v (%v)", habits.Habits, err)
+		}
+
+		if err := habits.Execute(command.NewCommand("undo")); err != nil || len(habits.Habits) != 1 {
+			t.Errorf("expected the import to be undone, got %+v (%v)", habits.Habits, err)
+		}
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		habits, _ := newCSVHabits(t)
+		err := habits.Execute(command.NewCommand("import xls file.xls"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package habits
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayStart is the wall-clock time at which a day begins, in minutes after midnight.
+// Checks before it count toward the previous day.
+type DayStart int16
+
+// ParseDayStart accepts a time of the day as HH:MM, e.g. 04:00.
+func ParseDayStart(value string) (DayStart, error) {
+	t, err := time.Parse("15:04", value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid day start %q, expected HH:MM, e.g. 04:00", value)
+	}
+
+	return DayStart(t.Hour()*60 + t.Minute()), nil
+}
+
+func (d DayStart) String() string {
+	return fmt.Sprintf("%02d:%02d", d/60, d%60)
+}
+
+func (d DayStart) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *DayStart) UnmarshalText(text []byte) error {
+	parsed, err := ParseDayStart(string(text))
+
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package habits
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDayStart(t *testing.T) {
+	var tests = []struct {
+		value string
+		want  DayStart
+	}{
+		{"00:00", 0},
+		{"04:00", 240},
+		{"4:30", 270},
+		{"23:59", 1439},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("parses a day start", func(t *testing.T) {
+			got, err := ParseDayStart(tt.value)
+
+			if err != nil || got != tt.want {
+				t.Errorf("invalid day start of %q, expected: %d, got: %d (%v)", tt.value, tt.want, got, err)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "24:00", "4", "04:60", "4am"} {
+
+		t.Run("rejects an invalid day start", func(t *testing.T) {
+			if _, err := ParseDayStart(value); err == nil {
+				t.Errorf("expected an error for %q", value)
+			}
+		})
+	}
+}
+
+func TestDayStartJSON(t *testing.T) {
+
+	t.Run("is stored as HH:MM", func(t *testing.T) {
+		data, _ := json.Marshal(struct{ DayStartsAt DayStart }{DayStart(270)})
+
+		if string(data) != `{"DayStartsAt":"04:30"}` {
+			t.Errorf("invalid JSON: %s", data)
+		}
+
+		var decoded struct{ DayStartsAt DayStart }
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded.DayStartsAt != 270 {
+			t.Errorf("invalid day start: %d (%v)", decoded.DayStartsAt, err)
+		}
+	})
+}
@@ -0,0 +1,520 @@
+package habits
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/storage"
+)
+
+const eventsSuffix = ".events"
+const EventsPerCheckpoint = 500 // events after which the log is rotated and started again from a checkpoint
+
+type EventType string
+
+const (
+	EventSnapshot           EventType = "snapshot"   // the whole state, e.g. of a restore
+	EventCheckpoint         EventType = "checkpoint" // the whole state for a faster load, skipped by rebuild after the first event
+	EventCreated            EventType = "created"
+	EventDeleted            EventType = "deleted"
+	EventChecked            EventType = "checked"
+	EventUnchecked          EventType = "unchecked"
+	EventStepsCountChanged  EventType = "steps_count_changed"
+	EventStepMinutesChanged EventType = "step_minutes_changed"
+	EventScheduleChanged    EventType = "schedule_changed"
+	EventFrozen             EventType = "frozen"
+	EventUnfrozen           EventType = "unfrozen"
+	EventDaysClosed         EventType = "days_closed"
+	EventDayLogged          EventType = "day_logged"
+	EventDayImported        EventType = "day_imported"
+	EventDayRemoved         EventType = "day_removed"
+	EventHabitRestored      EventType = "habit_restored" // a habit as it was on the day of the Date, e.g. by an undo
+	EventTimeZoneChanged    EventType = "time_zone_changed"
+	EventDayStartChanged    EventType = "day_start_changed"
+)
+
+// Event is an action on the habits. The habits are the result of applying the events in order,
+// the data file is a snapshot of them after the event with the EventSeq number.
+type Event struct {
+	Seq         int
+	At          time.Time
+	Type        EventType
+	Habit       int             `json:",omitempty"` // ID, 0 means all habits or the data file
+	Name        string          `json:",omitempty"`
+	StepsCount  int8            `json:",omitempty"`
+	StepMinutes int16           `json:",omitempty"`
+	Steps       int8            `json:",omitempty"`
+	Schedule    *Schedule       `json:",omitempty"`
+	Date        *time.Time      `json:",omitempty"`
+	TimeZone    string          `json:",omitempty"`
+	DayStart    *DayStart       `json:",omitempty"`
+	Entry       *Entry          `json:",omitempty"`
+	State       json.RawMessage `json:",omitempty"`
+}
+
+// emit applies the event and queues it, it is appended to the log by the next Save.
+func (h *Habits) emit(e Event) error {
+	e.At = h.now()
+
+	if err := h.apply(e); err != nil {
+		return err
+	}
+
+	h.appendEvent(e)
+
+	return nil
+}
+
+// emitForHabit applies the event to the referenced habit.
+func (h *Habits) emitForHabit(ref string, e Event) error {
+	habit, err := h.Get(ref)
+
+	if err != nil {
+		return err
+	}
+
+	e.Habit = habit.ID
+
+	return h.emit(e)
+}
+
+// appendEvent queues an event which has already been applied.
+func (h *Habits) appendEvent(e Event) {
+	h.EventSeq += 1
+	e.Seq = h.EventSeq
+	h.pending = append(h.pending, e)
+}
+
+// appendCheckpoint logs the current state, so the log can be replayed from it.
+func (h *Habits) appendCheckpoint() {
+	state, err := json.Marshal(h)
+
+	if err == nil {
+		h.appendEvent(Event{At: h.now(), Type: EventCheckpoint, State: state})
+	}
+}
+
+func (h *Habits) apply(e Event) error {
+	switch e.Type {
+	case EventSnapshot, EventCheckpoint:
+		return h.applySnapshot(e.State)
+	case EventCreated:
+		schedule := Schedule{}
+		if e.Schedule != nil {
+			schedule = *e.Schedule
+		}
+
+		return h.create(e.Name, e.StepsCount, e.StepMinutes, schedule, e.At)
+	case EventDaysClosed:
+		h.closeDays(e.At.In(h.location))
+		return nil
+	case EventTimeZoneChanged:
+		return h.SetTimeZone(e.TimeZone)
+	case EventHabitRestored:
+		return h.restoreHabit(e)
+	}
+
+	if e.Habit == 0 {
+		switch e.Type {
+		case EventFrozen:
+			h.Freeze()
+		case EventUnfrozen:
+			h.Unfreeze()
+		case EventDayStartChanged:
+			if e.DayStart == nil {
+				return errors.New("day start of the data file cannot be removed")
+			}
+
+			h.DayStartsAt = *e.DayStart
+		default:
+			return fmt.Errorf("event %q requires a habit", e.Type)
+		}
+
+		return nil
+	}
+
+	ref := strconv.Itoa(e.Habit)
+	habit, err := h.Get(ref)
+
+	if err != nil {
+		return err
+	}
+
+	switch e.Type {
+	case EventDeleted:
+		return h.Delete(ref)
+	case EventChecked:
+		for range e.Steps {
+			habit.CheckStep()
+		}
+	case EventUnchecked:
+		for range e.Steps {
+			habit.UncheckStep()
+		}
+	case EventStepsCountChanged:
+		return habit.SetStepsCount(e.StepsCount)
+	case EventStepMinutesChanged:
+		return habit.SetStepMinutes(e.StepMinutes)
+	case EventScheduleChanged:
+		if e.Schedule == nil {
+			return errors.New("schedule is missing")
+		}
+
+		habit.Schedule = *e.Schedule
+	case EventFrozen:
+		habit.Freeze()
+	case EventUnfrozen:
+		habit.Unfreeze()
+	case EventDayLogged:
+		if e.Date == nil {
+			return errors.New("date is missing")
+		}
+
+		habit.LogDay(*e.Date, e.Steps)
+	case EventDayImported:
+		if e.Entry == nil {
+			return errors.New("entry is missing")
+		}
+
+		habit.Summary.History = insertEntry(habit.Summary.History, *e.Entry)
+		habit.recalculate()
+	case EventDayRemoved:
+		if e.Date == nil {
+			return errors.New("date is missing")
+		}
+
+		habit.Summary.History = slices.DeleteFunc(habit.Summary.History, func(entry Entry) bool { return entry.Date.Equal(*e.Date) })
+		habit.recalculate()
+	case EventDayStartChanged:
+		habit.DayStartsAt = e.DayStart
+	default:
+		return fmt.Errorf("unknown event %q", e.Type)
+	}
+
+	return nil
+}
+
+// restoreHabit puts back the habit of the state. A missing habit is restored with its history, a tracked one
+// keeps its history, which is changed by the day events. When the day of the state has been closed since,
+// the restored day is written to the history and the current one is kept.
+func (h *Habits) restoreHabit(e Event) error {
+	var restored Habit
+
+	if err := json.Unmarshal(e.State, &restored); err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.ID == restored.ID })
+
+	if idx < 0 {
+		pos := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.ID > restored.ID })
+		if pos < 0 {
+			pos = len(h.Habits)
+		}
+
+		restored.recalculate()
+		h.Habits = slices.Insert(h.Habits, pos, restored)
+		h.NextID = max(h.NextID, restored.ID+1)
+
+		return nil
+	}
+
+	habit := &h.Habits[idx]
+	restored.Summary.History = habit.Summary.History
+
+	if e.Date != nil {
+		day, found := slices.BinarySearchFunc(restored.Summary.History, *e.Date, func(entry Entry, date time.Time) int {
+			return entry.Date.Compare(date)
+		})
+
+		if found {
+			restored.Summary.History[day] = restored.getCurrentEntry(restored.Summary.History[day].Date)
+			restored.CheckedSteps = habit.CheckedSteps
+		}
+	}
+
+	restored.recalculate()
+	*habit = restored
+
+	return nil
+}
+
+// applySnapshot replaces the habits with the state, keeping the position in the log.
+func (h *Habits) applySnapshot(state []byte) error {
+	restored := NewHabits(h.clock)
+
+	if err := restored.decode(state); err != nil {
+		return err
+	}
+
+	seq, pending := h.EventSeq, h.pending
+	h.replace(restored)
+	h.EventSeq, h.pending = seq, pending
+
+	return nil
+}
+
+// readEvents returns the events logged next to the data file. An incomplete last line,
+// left by an interrupted write, is skipped.
+func readEvents(path string) ([]Event, error) {
+	return readEventsFile(path + eventsSuffix)
+}
+
+// readArchivedEvents returns the events of the logs which have been rotated, from the oldest.
+func readArchivedEvents(path string) ([]Event, error) {
+	names, err := filepath.Glob(path + eventsSuffix + ".*")
+
+	if err != nil {
+		return nil, err
+	}
+
+	archives := map[int]string{}
+	numbers := []int{}
+
+	for _, name := range names {
+		if seq, err := strconv.Atoi(strings.TrimPrefix(name, path+eventsSuffix+".")); err == nil {
+			archives[seq] = name
+			numbers = append(numbers, seq)
+		}
+	}
+
+	slices.Sort(numbers)
+	events := []Event{}
+
+	for _, seq := range numbers {
+		archived, err := readEventsFile(archives[seq])
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, archived...)
+	}
+
+	return events, nil
+}
+
+func readEventsFile(name string) ([]Event, error) {
+	file, err := os.Open(name)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return []Event{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	events := []Event{}
+	reader := bufio.NewReader(file)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid event after %d: %w", len(events), err)
+		}
+
+		events = append(events, e)
+	}
+}
+
+func encodeEvents(events []Event) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, e := range events {
+		line, err := json.Marshal(e)
+
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeEvents(path string, events []Event) error {
+	data, err := encodeEvents(events)
+
+	if err != nil {
+		return err
+	}
+
+	if err := dropIncompleteEvent(path + eventsSuffix); err != nil {
+		return err
+	}
+
+	return storage.AppendFile(path+eventsSuffix, data, 0644)
+}
+
+// rotateEvents moves the log aside, named after its last event, and starts it again from the checkpoint,
+// which has already been logged as its last event. The rotated logs are kept for rebuild.
+func rotateEvents(path string, checkpoint Event) error {
+	data, err := encodeEvents([]Event{checkpoint})
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(path+eventsSuffix, fmt.Sprintf("%s%s.%d", path, eventsSuffix, checkpoint.Seq)); err != nil {
+		return err
+	}
+
+	return storage.WriteFileAtomic(path+eventsSuffix, data, 0644)
+}
+
+// dropIncompleteEvent truncates an incomplete last line, left by an interrupted write, so the events
+// are not appended to it. Only the tail of the log is read.
+func dropIncompleteEvent(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	end := info.Size()
+	last := make([]byte, 1)
+
+	if end == 0 {
+		return nil
+	}
+
+	if _, err := file.ReadAt(last, end-1); err != nil {
+		return err
+	}
+
+	if last[0] == '\n' {
+		return nil
+	}
+
+	chunk := make([]byte, 4096)
+
+	for end > 0 {
+		start := max(0, end-int64(len(chunk)))
+
+		if _, err := file.ReadAt(chunk[:end-start], start); err != nil {
+			return err
+		}
+
+		if idx := bytes.LastIndexByte(chunk[:end-start], '\n'); idx >= 0 {
+			return file.Truncate(start + int64(idx) + 1)
+		}
+
+		end = start
+	}
+
+	return file.Truncate(0)
+}
+
+// replayTail applies the logged events which are newer than the snapshot,
+// e.g. when the snapshot has not been saved after the events. It returns the number of the last logged event.
+func (h *Habits) replayTail() (int, error) {
+	events, err := readEvents(h.path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	last := 0
+	for _, e := range events {
+		last = e.Seq
+
+		if e.Seq <= h.EventSeq {
+			continue
+		}
+
+		if err := h.apply(e); err != nil {
+			return 0, fmt.Errorf("event %d cannot be replayed: %w", e.Seq, err)
+		}
+
+		h.EventSeq = e.Seq
+	}
+
+	return last, nil
+}
+
+// rebuild recomputes the habits by replaying all the events from the beginning, the rotated logs first.
+// A checkpoint is applied only when the events before it are missing, e.g. at the start of the log,
+// otherwise the habits are recomputed from the events.
+func (h *Habits) rebuild() (int, error) {
+	events, err := readArchivedEvents(h.path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	logged, err := readEvents(h.path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	events = append(append(events, logged...), h.pending...)
+
+	if len(events) == 0 || events[0].Type != EventSnapshot && events[0].Type != EventCheckpoint {
+		return 0, errors.New("the event log does not start with a snapshot and cannot be replayed")
+	}
+
+	rebuilt := NewHabits(h.clock)
+	count := 0
+
+	for _, e := range events {
+		if count > 0 && e.Seq <= rebuilt.EventSeq {
+			// The checkpoint which starts a rotated log is also the last event of the previous one
+			continue
+		}
+
+		if count > 0 && e.Type == EventCheckpoint && e.Seq == rebuilt.EventSeq+1 {
+			rebuilt.EventSeq = e.Seq
+			continue
+		}
+
+		if err := rebuilt.apply(e); err != nil {
+			return 0, fmt.Errorf("event %d cannot be replayed: %w", e.Seq, err)
+		}
+
+		rebuilt.EventSeq = e.Seq
+		count += 1
+	}
+
+	rebuilt.pending = h.pending
+	h.replace(rebuilt)
+
+	return count, nil
+}
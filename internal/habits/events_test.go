@@ -0,0 +1,271 @@
+package habits
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+func TestEvents(t *testing.T) {
+
+	t.Run("logs every change", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 2 60"))
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("f test"))
+
+		events, err := readEvents(habits.Path())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []EventType{EventCheckpoint, EventCreated, EventChecked, EventFrozen}
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %v", len(expected), events)
+		}
+
+		for idx, e := range events {
+			if e.Type != expected[idx] || e.Seq != idx+1 {
+				t.Errorf("expected event %d to be %q, got %q (%d)", idx+1, expected[idx], e.Type, e.Seq)
+			}
+		}
+
+		if habits.EventSeq != len(expected) {
+			t.Errorf("expected EventSeq to be %d, got %d", len(expected), habits.EventSeq)
+		}
+	})
+
+	t.Run("does not log a failed command", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("cs missing 3"))
+
+		events, _ := readEvents(habits.Path())
+
+		if len(events) != 2 {
+			t.Errorf("expected %d events, got %v", 2, events)
+		}
+	})
+
+	t.Run("logs the closed days", func(t *testing.T) {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		fake.AdvanceDays(2)
+		habits.Execute(command.NewCommand("c test"))
+
+		events, _ := readEvents(habits.Path())
+
+		if len(events) != 4 || events[2].Type != EventDaysClosed {
+			t.Errorf("expected the days to be closed before the check, got %v", events)
+		}
+	})
+
+	t.Run("rebuilds the habits from the log", func(t *testing.T) {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Test 2 60"))
+		habits.Execute(command.NewCommand("c test 2"))
+		fake.AdvanceDays(1)
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("log test -1 1"))
+
+		expected, _ := json.Marshal(habits.Habits)
+
+		habits.Habits[0].Summary.LongestStreak = 42
+		habits.Habits[0].CheckedSteps = 0
+		habits.Save(habits.Path())
+
+		if err := habits.Execute(command.NewCommand("rebuild")); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := json.Marshal(habits.Habits)
+		if string(actual) != string(expected) {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	})
+
+	t.Run("replays the events missing in the data file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		habits := NewHabits(clock.NewFake(day))
+		habits.Load(path)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		snapshot, _ := os.ReadFile(path)
+		habits.Execute(command.NewCommand("cs test 3"))
+		os.WriteFile(path, snapshot, 0644)
+
+		loaded := NewHabits(clock.NewFake(day))
+
+		if err := loaded.Load(path); err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.Habits[0].StepsCount != 3 || loaded.EventSeq != 3 {
+			t.Errorf("expected the logged change to be replayed, got %+v (%d)", loaded.Habits[0], loaded.EventSeq)
+		}
+	})
+
+	t.Run("starts the log of an existing data file with a checkpoint", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		habits := NewHabits(clock.NewFake(day))
+		habits.Load(path)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		os.Remove(path + eventsSuffix)
+
+		loaded := NewHabits(clock.NewFake(day))
+		loaded.Load(path)
+		loaded.Execute(command.NewCommand("c test"))
+
+		events, _ := readEvents(path)
+
+		if len(events) != 2 || events[0].Type != EventCheckpoint || events[0].Seq != 3 {
+			t.Fatalf("expected a checkpoint and a check, got %v", events)
+		}
+
+		if err := loaded.Execute(command.NewCommand("rebuild")); err != nil || loaded.Habits[0].CheckedSteps != 1 {
+			t.Errorf("habits have not been rebuilt: %v (%v)", loaded.Habits, err)
+		}
+	})
+
+	t.Run("rotates the log every EventsPerCheckpoint events", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 2 60"))
+		habits.EventSeq = EventsPerCheckpoint - 1
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("c test"))
+
+		archived, err := readEventsFile(fmt.Sprintf("%s%s.%d", habits.Path(), eventsSuffix, EventsPerCheckpoint+1))
+
+		if err != nil || len(archived) != 4 || archived[3].Type != EventCheckpoint {
+			t.Fatalf("expected the log to be rotated after a checkpoint, got %v (%v)", archived, err)
+		}
+
+		events, _ := readEvents(habits.Path())
+
+		if len(events) != 2 || events[0].Type != EventCheckpoint || events[0].Seq != EventsPerCheckpoint+1 || events[1].Type != EventChecked {
+			t.Fatalf("expected the log to start from the checkpoint, got %v", events)
+		}
+
+		loaded := NewHabits(clock.NewFake(day))
+		loaded.Load(habits.Path())
+
+		if loaded.Habits[0].CheckedSteps != 2 || loaded.EventSeq != EventsPerCheckpoint+2 {
+			t.Errorf("expected the rotated log to be loaded, got %+v (%d)", loaded.Habits, loaded.EventSeq)
+		}
+
+		loaded.Habits[0].CheckedSteps = 0
+
+		if err := loaded.Execute(command.NewCommand("rebuild")); err != nil || loaded.Habits[0].CheckedSteps != 2 {
+			t.Errorf("expected the habits to be rebuilt from the rotated logs, got %+v (%v)", loaded.Habits, err)
+		}
+	})
+
+	t.Run("rebuilds the habits from the events before a checkpoint", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 2 60"))
+		habits.EventSeq = EventsPerCheckpoint - 1
+		// A wrong state, e.g. of a bug which has been fixed since, is saved with the checkpoint
+		habits.Habits[0].StepMinutes = 5
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("c test"))
+
+		if err := habits.Execute(command.NewCommand("rebuild")); err != nil || habits.Habits[0].StepMinutes != 60 || habits.Habits[0].CheckedSteps != 2 {
+			t.Errorf("expected the habit to be recomputed from the events, got %+v (%v)", habits.Habits, err)
+		}
+	})
+
+	t.Run("logs an undo as the restored habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("d test"))
+		habits.Execute(command.NewCommand("undo"))
+
+		events, _ := readEvents(habits.Path())
+
+		if last := events[len(events)-1]; last.Type != EventHabitRestored || last.Habit != 1 {
+			t.Errorf("expected the undo to be logged as the restored habit, got %+v", last)
+		}
+
+		if err := habits.Execute(command.NewCommand("rebuild")); err != nil || len(habits.Habits) != 1 {
+			t.Errorf("undone deletion has not been rebuilt: %v (%v)", habits.Habits, err)
+		}
+	})
+
+	t.Run("skips an incomplete last event", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+
+		file, _ := os.OpenFile(habits.Path()+eventsSuffix, os.O_APPEND|os.O_WRONLY, 0644)
+		file.WriteString(`{"Seq":3,"Type":"chec`)
+		file.Close()
+
+		events, err := readEvents(habits.Path())
+
+		if err != nil || len(events) != 2 {
+			t.Errorf("expected %d events, got %v (%v)", 2, events, err)
+		}
+	})
+
+	t.Run("drops an incomplete last event before appending", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+
+		file, _ := os.OpenFile(habits.Path()+eventsSuffix, os.O_APPEND|os.O_WRONLY, 0644)
+		file.WriteString(`{"Seq":3,"Type":"chec`)
+		file.Close()
+
+		habits.Execute(command.NewCommand("c test"))
+		events, err := readEvents(habits.Path())
+
+		if err != nil || len(events) != 3 || events[2].Type != EventChecked {
+			t.Errorf("expected the check to be the last event, got %v (%v)", events, err)
+		}
+	})
+}
+
+func TestDropIncompleteEvent(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	var tests = []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"keeps a complete log", "{}\n{}\n", "{}\n{}\n"},
+		{"drops the incomplete last line", "{}\n{\"Seq\":2", "{}\n"},
+		{"drops a last line longer than a chunk", "{}\n" + long + "\n{\"Seq\":3," + long, "{}\n" + long + "\n"},
+		{"empties a log of an incomplete line", long, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "habits.json.events")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			if err := dropIncompleteEvent(path); err != nil {
+				t.Fatal(err)
+			}
+
+			if data, _ := os.ReadFile(path); string(data) != tt.want {
+				t.Errorf("expected %d bytes, got %d", len(tt.want), len(data))
+			}
+		})
+	}
+
+	t.Run("ignores a missing log", func(t *testing.T) {
+		if err := dropIncompleteEvent(filepath.Join(t.TempDir(), "habits.json.events")); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package habits
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"gopkg.in/yaml.v3"
+)
+
+// ListFormats are the output formats of the list command, table is the default one.
+var ListFormats = []string{"table", "json", "yaml", "csv", "markdown", "html"}
+
+// habitJSON is the schema of the JSON and YAML output, it is documented in the README.
+// Fields may be added but are never renamed or removed, so the output can be relied on by scripts.
+type habitJSON struct {
+	ID            int         `json:"id" yaml:"id"`
+	Name          string      `json:"name" yaml:"name"`
+	CheckedSteps  int8        `json:"checkedSteps" yaml:"checkedSteps"`
+	StepsCount    int8        `json:"stepsCount" yaml:"stepsCount"`
+	StepMinutes   int16       `json:"stepMinutes" yaml:"stepMinutes"`
+	Schedule      string      `json:"schedule" yaml:"schedule"`
+	IsFrozen      bool        `json:"isFrozen" yaml:"isFrozen"`
+	CurrentStreak int16       `json:"currentStreak" yaml:"currentStreak"`
+	LongestStreak int16       `json:"longestStreak" yaml:"longestStreak"`
+	Today         string      `json:"today" yaml:"today"` // YYYY-MM-DD, the current day of the habit
+	IsDueToday    bool        `json:"isDueToday" yaml:"isDueToday"`
+	MinutesToday  int         `json:"minutesToday" yaml:"minutesToday"`
+	TotalMinutes  int         `json:"totalMinutes" yaml:"totalMinutes"` // today included
+	History       []entryJSON `json:"history" yaml:"history"`           // closed days, the oldest first
+}
+
+type entryJSON struct {
+	Date         string `json:"date" yaml:"date"`
+	StepsCount   int8   `json:"stepsCount" yaml:"stepsCount"`
+	CheckedSteps int8   `json:"checkedSteps" yaml:"checkedSteps"`
+	Minutes      int    `json:"minutes" yaml:"minutes"`
+	IsFrozen     bool   `json:"isFrozen" yaml:"isFrozen"`
+	IsOffDay     bool   `json:"isOffDay" yaml:"isOffDay"`
+}
+
+var habitColumns = []string{"id", "name", "checkedSteps", "stepsCount", "stepMinutes", "schedule", "isFrozen",
+	"currentStreak", "longestStreak", "today", "isDueToday", "minutesToday", "totalMinutes", "history"}
+
+func (h *Habits) newHabitJSON(habit Habit) habitJSON {
+	today := h.today(&habit)
+	current := habit.getCurrentEntry(today)
+	history := make([]entryJSON, len(habit.Summary.History))
+
+	for idx, entry := range habit.Summary.History {
+		history[idx] = entryJSON{
+			Date:         entry.Date.Format(time.DateOnly),
+			StepsCount:   entry.StepsCount,
+			CheckedSteps: entry.CheckedSteps,
+			Minutes:      entry.Minutes(),
+			IsFrozen:     entry.IsFrozen,
+			IsOffDay:     entry.IsOffDay,
+		}
+	}
+
+	return habitJSON{
+		ID:            habit.ID,
+		Name:          habit.Name,
+		CheckedSteps:  habit.CheckedSteps,
+		StepsCount:    habit.StepsCount,
+		StepMinutes:   habit.StepMinutes,
+		Schedule:      habit.Schedule.String(),
+		IsFrozen:      habit.IsFrozen,
+		CurrentStreak: habit.Summary.CurrentStreak,
+		LongestStreak: habit.Summary.LongestStreak,
+		Today:         today.Format(time.DateOnly),
+		IsDueToday:    !habit.IsFrozen && !current.IsOffDay,
+		MinutesToday:  current.Minutes(),
+		TotalMinutes:  habit.Summary.TotalTime.InMinutes() + current.Minutes(),
+		History:       history,
+	}
+}
+
+// stringifyPlainHistory is the history column of the tabular formats, the checked steps of the recent days
+// and the current one, F for a frozen day and - for an off day without checked steps.
+func stringifyPlainHistory(habit Habit, today time.Time) string {
+	days := []string{}
+	history := slices.Concat(habit.Summary.History[max(0, len(habit.Summary.History)-int(HistoryLen)):], []Entry{habit.getCurrentEntry(today)})
+
+	for _, entry := range history {
+		switch {
+		case entry.IsFrozen:
+			days = append(days, "F")
+		case entry.IsOffDay && entry.CheckedSteps == 0:
+			days = append(days, "-")
+		default:
+			days = append(days, strconv.Itoa(int(entry.CheckedSteps)))
+		}
+	}
+
+	return strings.Join(days, " ")
+}
+
+// newFormatRow is a CSV row of the habit, in the order of habitColumns.
+func newFormatRow(item habitJSON, history string) []string {
+	row := []string{}
+
+	for _, value := range []any{item.ID, item.Name, item.CheckedSteps, item.StepsCount, item.StepMinutes, item.Schedule, item.IsFrozen,
+		item.CurrentStreak, item.LongestStreak, item.Today, item.IsDueToday, item.MinutesToday, item.TotalMinutes, history} {
+		row = append(row, fmt.Sprint(value))
+	}
+
+	return row
+}
+
+// WriteFormat writes the habits in one of ListFormats, without colors except for the table.
+func (h *Habits) WriteFormat(w io.Writer, format string, habits ...Habit) error {
+	items := make([]habitJSON, len(habits))
+	for idx, habit := range habits {
+		items[idx] = h.newHabitJSON(habit)
+	}
+
+	switch format {
+	case "table":
+		fmt.Fprintln(w, h.renderTable(habits...))
+	case "json":
+		data, err := json.MarshalIndent(items, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(items)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(w, string(data))
+	case "csv":
+		// The table writer escapes the commas of a schedule instead of quoting the field
+		writer := csv.NewWriter(w)
+		writer.Write(habitColumns)
+
+		for idx, item := range items {
+			writer.Write(newFormatRow(item, stringifyPlainHistory(habits[idx], h.today(&habits[idx]))))
+		}
+
+		writer.Flush()
+
+		return writer.Error()
+	case "markdown", "html":
+		t := table.NewWriter()
+		t.Style().Format.Header = text.FormatDefault
+
+		header := table.Row{}
+		for _, column := range habitColumns {
+			header = append(header, column)
+		}
+		t.AppendHeader(header)
+
+		for idx, item := range items {
+			t.AppendRow(table.Row{item.ID, item.Name, item.CheckedSteps, item.StepsCount, item.StepMinutes, item.Schedule, item.IsFrozen,
+				item.CurrentStreak, item.LongestStreak, item.Today, item.IsDueToday, item.MinutesToday, item.TotalMinutes,
+				stringifyPlainHistory(habits[idx], h.today(&habits[idx]))})
+		}
+
+		if format == "markdown" {
+			fmt.Fprintln(w, t.RenderMarkdown())
+		} else {
+			fmt.Fprintln(w, t.RenderHTML())
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(ListFormats, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/seektor/habits-tracker-go/internal/command"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteFormat(t *testing.T) {
+	// Read: 2 of 2 steps on 2020-11-20, frozen on 2020-11-21 and 1 step checked today
+	habits, _ := newCSVHabits(t)
+
+	write := func(t *testing.T, format string) string {
+		var buf bytes.Buffer
+
+		if err := habits.WriteFormat(&buf, format, habits.Habits...); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.String()
+	}
+
+	t.Run("writes the schema as JSON", func(t *testing.T) {
+		var items []map[string]any
+
+		if err := json.Unmarshal([]byte(write(t, "json")), &items); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, column := range habitColumns {
+			if _, ok := items[0][column]; !ok {
+				t.Errorf("expected the field %s, got %v", column, items[0])
+			}
+		}
+
+		var decoded []habitJSON
+		json.Unmarshal([]byte(write(t, "json")), &decoded)
+		item := decoded[0]
+
+		if item.Today != "2020-11-22" || item.MinutesToday != 30 || item.TotalMinutes != 90 || !item.IsDueToday {
+			t.Errorf("expected the progress of today to be included, got %+v", item)
+		}
+
+		expected := []entryJSON{
+			{Date: "2020-11-20", StepsCount: 2, CheckedSteps: 2, Minutes: 60},
+			{Date: "2020-11-21", IsFrozen: true},
+		}
+
+		if len(item.History) != 2 || item.History[0] != expected[0] || item.History[1] != expected[1] {
+			t.Errorf("expected the history %+v, got %+v", expected, item.History)
+		}
+	})
+
+	t.Run("writes the same schema as YAML", func(t *testing.T) {
+		var fromJSON, fromYAML []habitJSON
+		json.Unmarshal([]byte(write(t, "json")), &fromJSON)
+
+		if err := yaml.Unmarshal([]byte(write(t, "yaml")), &fromYAML); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(fromYAML) != 1 || fromYAML[0].TotalMinutes != fromJSON[0].TotalMinutes || len(fromYAML[0].History) != len(fromJSON[0].History) {
+			t.Errorf("expected %+v, got %+v", fromJSON, fromYAML)
+		}
+	})
+
+	t.Run("writes the tabular formats without colors", func(t *testing.T) {
+		expected := "id,name,checkedSteps,stepsCount,stepMinutes,schedule,isFrozen,currentStreak,longestStreak,today,isDueToday,minutesToday,totalMinutes,history\n" +
+			"1,Read,1,2,30,daily,false,1,1,2020-11-22,true,30,90,2 F 1\n"
+
+		if output := write(t, "csv"); output != expected {
+			t.Errorf("expected %q, got %q", expected, output)
+		}
+
+		gym, _ := newCSVHabits(t)
+		gym.Execute(command.NewCommand("a Gym 1 60 mon,wed"))
+
+		var buf bytes.Buffer
+		if err := gym.WriteFormat(&buf, "csv", gym.Habits[1]); err != nil {
+			t.Fatal(err)
+		}
+
+		// 2020-11-22 is a Sunday, on which Gym is not scheduled
+		expectedGym := "\n2,Gym,0,1,60,\"mon,wed\",false,0,0,2020-11-22,false,0,0,-\n"
+		if output := buf.String(); !strings.HasSuffix(output, expectedGym) {
+			t.Errorf("expected the schedule to be quoted, got %q", output)
+		}
+
+		for _, format := range []string{"markdown", "html"} {
+			output := write(t, format)
+
+			if !strings.Contains(output, "totalMinutes") || !strings.Contains(output, "2 F 1") || strings.Contains(output, "\033[") {
+				t.Errorf("expected a %s table without colors, got %s", format, output)
+			}
+		}
+	})
+
+	t.Run("writes an empty list", func(t *testing.T) {
+		var buf bytes.Buffer
+		habits.WriteFormat(&buf, "json", []Habit{}...)
+
+		if strings.TrimSpace(buf.String()) != "[]" {
+			t.Errorf("expected an empty array, got %q", buf.String())
+		}
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		err := habits.Execute(command.NewCommand("p --format=xml"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
@@ -2,39 +2,61 @@ package habits
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
 )
 
 const MaxHabitNameLength int8 = 16
 const MaxHabitTotalTime int16 = 16 * 60 // minutes
-const HistoryLen int8 = 6
+const HistoryLen int8 = 6               // number of past days shown in the history column
 
 type Entry struct {
-	CheckedSteps int8
+	Date         time.Time
 	StepsCount   int8
+	StepMinutes  int16 // minutes
+	CheckedSteps int8
 	IsFrozen     bool
+	IsOffDay     bool // the habit was not scheduled on that day
+	WeeklyTimes  int8 // the weekly quota of a ScheduleTimesPerWeek habit
 }
+
+// Baseline holds the statistics gathered before the first entry of the history,
+// e.g. the days which were dropped by the fixed six-day window of older files.
+type Baseline struct {
+	TotalTime     TotalTime
+	LongestStreak int16
+	CurrentStreak int16
+}
+
+// Summary statistics are always recalculated from the Baseline and the History.
 type Summary struct {
 	TotalTime     TotalTime
 	LongestStreak int16
 	CurrentStreak int16
-	History       [HistoryLen]Entry // History of last 6 days
+	Baseline      Baseline
+	History       []Entry // One entry per closed day, ordered by date
 }
 
 type Habit struct {
+	ID           int
 	Name         string
 	CreatedAt    time.Time
 	StepsCount   int8
 	StepMinutes  int16 // minutes
 	CheckedSteps int8
 	IsFrozen     bool
+	Schedule     Schedule
+	DayStartsAt  *DayStart `json:",omitempty"` // overrides the day start of the data file
 	Summary      Summary
 }
 
-func newHabit(name string, stepsCount int8, stepTime int16) Habit {
+func newHabit(name string, stepsCount int8, stepTime int16, createdAt time.Time) Habit {
 	habit := Habit{
 		Name:         name,
-		CreatedAt:    time.Now(),
+		CreatedAt:    createdAt,
 		StepsCount:   stepsCount,
 		StepMinutes:  stepTime,
 		CheckedSteps: 0,
@@ -43,7 +65,8 @@ func newHabit(name string, stepsCount int8, stepTime int16) Habit {
 			TotalTime:     TotalTime{},
 			LongestStreak: 0,
 			CurrentStreak: 0,
-			History:       [HistoryLen]Entry{},
+			Baseline:      Baseline{},
+			History:       make([]Entry, 0),
 		},
 	}
 
@@ -55,7 +78,9 @@ func (h *Habit) CheckStep() {
 		return
 	}
 
-	h.CheckedSteps += 1
+	if h.CheckedSteps < math.MaxInt8 {
+		h.CheckedSteps += 1
+	}
 }
 
 func (h *Habit) UncheckStep() {
@@ -113,59 +138,149 @@ func (h *Habit) Unfreeze() {
 	h.IsFrozen = false
 }
 
-func (h *Habit) getCurrentEntry() Entry {
+// Minutes is computed in int, since the checked steps of an over-achieved day may exceed the goal many times.
+func (e Entry) Minutes() int {
+	return int(e.StepMinutes) * int(e.CheckedSteps)
+}
+
+func (e Entry) IsFulfilled() bool {
+	return e.CheckedSteps >= e.StepsCount
+}
+
+func (h *Habit) getCurrentEntry(date time.Time) Entry {
 	if h.IsFrozen {
-		return Entry{IsFrozen: true}
+		return Entry{Date: date, IsFrozen: true}
 	} else {
 		return Entry{
-			CheckedSteps: h.CheckedSteps,
+			Date:         date,
 			StepsCount:   h.StepsCount,
+			StepMinutes:  h.StepMinutes,
+			CheckedSteps: h.CheckedSteps,
+			IsOffDay:     !h.Schedule.IsDue(date),
+			WeeklyTimes:  h.Schedule.Times,
 		}
 	}
 }
 
-func (h *Habit) updateStatistics() {
-	if h.IsFrozen {
-		return
+type weekProgress struct {
+	hits     int8
+	days     int8
+	isClosed bool
+}
+
+// getWeeksProgress groups the history of a ScheduleTimesPerWeek habit by weeks.
+func getWeeksProgress(history []Entry) map[time.Time]*weekProgress {
+	weeks := map[time.Time]*weekProgress{}
+
+	if len(history) == 0 {
+		return weeks
 	}
 
-	h.Summary.TotalTime.Add(h.StepMinutes * int16(h.CheckedSteps))
+	lastDate := history[len(history)-1].Date
 
-	if h.CheckedSteps >= h.StepsCount {
-		h.Summary.CurrentStreak += 1
+	for _, entry := range history {
+		if entry.IsFrozen || entry.WeeklyTimes == 0 {
+			continue
+		}
+
+		weekStart := utils.GetBeginningOfWeekDate(entry.Date)
+		week, ok := weeks[weekStart]
 
-		if h.Summary.CurrentStreak > h.Summary.LongestStreak {
-			h.Summary.LongestStreak = h.Summary.CurrentStreak
+		if !ok {
+			week = &weekProgress{isClosed: utils.GetDaysDiff(weekStart, lastDate) >= 6}
+			weeks[weekStart] = week
+		}
+
+		week.days += 1
+		if entry.IsFulfilled() {
+			week.hits += 1
 		}
-	} else {
-		h.Summary.CurrentStreak = 0
 	}
+
+	return weeks
 }
 
-func (h *Habit) UpdateToPresent(daysDiff int32) {
-	if daysDiff <= 0 {
-		return
+// isMissed tells whether a not fulfilled entry breaks the streak.
+// Days of a ScheduleTimesPerWeek habit are missed only when the weekly quota has not been met.
+func (e Entry) isMissed(weeks map[time.Time]*weekProgress) bool {
+	if e.IsFrozen || e.IsOffDay || e.IsFulfilled() {
+		return false
 	}
 
-	firstDayEntryIdx := int32(HistoryLen) - daysDiff
+	if e.WeeklyTimes > 0 {
+		week := weeks[utils.GetBeginningOfWeekDate(e.Date)]
+		return week.isClosed && week.hits < min(e.WeeklyTimes, week.days)
+	}
 
-	if firstDayEntryIdx >= 0 {
-		// Shift history and save the current entry when it is within the history range
-		for i := range int(firstDayEntryIdx) {
-			h.Summary.History[i] = h.Summary.History[i+int(daysDiff)]
+	return true
+}
+
+func (h *Habit) recalculate() {
+	summary := &h.Summary
+	summary.TotalTime = summary.Baseline.TotalTime
+	summary.LongestStreak = summary.Baseline.LongestStreak
+	summary.CurrentStreak = summary.Baseline.CurrentStreak
+	weeks := getWeeksProgress(summary.History)
+
+	for _, entry := range summary.History {
+		if entry.IsFrozen {
+			continue
 		}
 
-		h.Summary.History[firstDayEntryIdx] = h.getCurrentEntry()
+		summary.TotalTime.Add(entry.Minutes())
+
+		switch {
+		case entry.isMissed(weeks):
+			summary.CurrentStreak = 0
+		case entry.IsFulfilled() && !entry.IsOffDay:
+			summary.CurrentStreak += 1
+			summary.LongestStreak = max(summary.LongestStreak, summary.CurrentStreak)
+		}
 	}
+}
 
-	// Update based on the values from the day of the last user activity
-	h.updateStatistics()
-	h.CheckedSteps = 0
+// LogDay sets the checked steps of a closed day and recalculates the summary from the history.
+// A day without an entry, e.g. before the habit was created, is added with the current settings of the habit.
+// A frozen day becomes a regular one.
+func (h *Habit) LogDay(date time.Time, checkedSteps int8) {
+	date = utils.GetBeginningOfDayDate(date)
+	idx, found := slices.BinarySearchFunc(h.Summary.History, date, func(e Entry, date time.Time) int {
+		return e.Date.Compare(date)
+	})
+
+	if !found || h.Summary.History[idx].IsFrozen {
+		entry := Entry{
+			Date:        date,
+			StepsCount:  h.StepsCount,
+			StepMinutes: h.StepMinutes,
+			IsOffDay:    !h.Schedule.IsDue(date),
+			WeeklyTimes: h.Schedule.Times,
+		}
+
+		if found {
+			h.Summary.History[idx] = entry
+		} else {
+			h.Summary.History = slices.Insert(h.Summary.History, idx, entry)
+		}
+	}
+
+	h.Summary.History[idx].CheckedSteps = checkedSteps
+	h.recalculate()
+}
 
-	batchEntryIdx := max(0, firstDayEntryIdx+1)
-	for i := batchEntryIdx; i < int32(HistoryLen); i++ {
-		// Fill the history between the day of the last user activity and today
-		h.Summary.History[i] = h.getCurrentEntry()
-		h.updateStatistics()
+// UpdateToPresent closes the day of the last user activity (from) and every day
+// between it and today.
+func (h *Habit) UpdateToPresent(from time.Time, daysDiff int32) {
+	if daysDiff <= 0 {
+		return
+	}
+
+	from = utils.GetBeginningOfDayDate(from)
+
+	for i := range int(daysDiff) {
+		h.Summary.History = append(h.Summary.History, h.getCurrentEntry(from.AddDate(0, 0, i)))
+		h.CheckedSteps = 0
 	}
+
+	h.recalculate()
 }
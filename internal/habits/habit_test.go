@@ -2,13 +2,15 @@ package habits
 
 import (
 	"fmt"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestCheckStep(t *testing.T) {
 
 	t.Run("checks a habit step", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
 		habit.CheckStep()
@@ -19,7 +21,7 @@ func TestCheckStep(t *testing.T) {
 	})
 
 	t.Run("does not check a habit when it is frozen", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.Freeze()
 		habit.CheckStep()
 
@@ -32,7 +34,7 @@ func TestCheckStep(t *testing.T) {
 func TestUncheckStep(t *testing.T) {
 
 	t.Run("unchecks a habit step", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
 		habit.UncheckStep()
@@ -53,7 +55,7 @@ func TestUncheckStep(t *testing.T) {
 func TestSetStepsCount(t *testing.T) {
 
 	t.Run(fmt.Sprintf("returns an error when the total habit time is longer than %d", MaxHabitTotalTime), func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		res := habit.SetStepsCount(17)
 
 		if res == nil {
@@ -62,7 +64,7 @@ func TestSetStepsCount(t *testing.T) {
 	})
 
 	t.Run("sets number of steps", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.SetStepsCount(3)
 
 		if habit.StepsCount != 3 {
@@ -74,7 +76,7 @@ func TestSetStepsCount(t *testing.T) {
 func TestSetStepMinutes(t *testing.T) {
 
 	t.Run(fmt.Sprintf("returns an error when the total habit time is longer than %d", MaxHabitTotalTime), func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		res := habit.SetStepMinutes(MaxHabitTotalTime + 1)
 
 		if res == nil {
@@ -83,7 +85,7 @@ func TestSetStepMinutes(t *testing.T) {
 	})
 
 	t.Run("sets number of steps", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.SetStepMinutes(30)
 
 		if habit.StepMinutes != 30 {
@@ -94,7 +96,7 @@ func TestSetStepMinutes(t *testing.T) {
 
 func TestFreeze(t *testing.T) {
 	t.Run("freezes the habit and clears the CheckedSteps count", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.CheckStep()
 		habit.Freeze()
 
@@ -110,7 +112,7 @@ func TestFreeze(t *testing.T) {
 
 func TestUnfreeze(t *testing.T) {
 	t.Run("unfreezes the habit", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.Unfreeze()
 
 		if habit.IsFrozen == true {
@@ -119,12 +121,12 @@ func TestUnfreeze(t *testing.T) {
 	})
 }
 
-func isUpdated(habit *Habit, currentStreak int16, longestStreak int16, totalTime TotalTime, history [HistoryLen]Entry) bool {
+func isUpdated(habit *Habit, currentStreak int16, longestStreak int16, totalTime TotalTime, history []Entry) bool {
 	isStreakUpdated := habit.Summary.CurrentStreak == currentStreak
 	isLongestStreakUpdated := habit.Summary.LongestStreak == longestStreak
 	isCheckedStepUpdated := habit.CheckedSteps == 0
 	isTotalTimeUpdated := habit.Summary.TotalTime == totalTime
-	isHistoryUpdated := habit.Summary.History == history
+	isHistoryUpdated := slices.Equal(habit.Summary.History, history)
 
 	return isStreakUpdated &&
 		isLongestStreakUpdated &&
@@ -133,14 +135,20 @@ func isUpdated(habit *Habit, currentStreak int16, longestStreak int16, totalTime
 		isHistoryUpdated
 }
 
+var day = time.Date(2020, 11, 20, 0, 0, 0, 0, time.UTC)
+
+func dayAfter(days int) time.Time {
+	return day.AddDate(0, 0, days)
+}
+
 func TestHabitUpdateToPresent(t *testing.T) {
 
 	t.Run("does not update when there is no day change", func(t *testing.T) {
-		habit := newHabit("Test", 1, 60)
+		habit := newHabit("Test", 1, 60, day)
 		habit.CheckStep()
-		habit.UpdateToPresent(0)
+		habit.UpdateToPresent(day, 0)
 
-		isUpdated := isUpdated(&habit, 0, 0, TotalTime{Hours: 0}, [HistoryLen]Entry{{}, {}, {}, {}, {}, {}})
+		isUpdated := isUpdated(&habit, 0, 0, TotalTime{Hours: 0}, []Entry{})
 
 		if isUpdated {
 			t.Error("habit has not been updated successfully")
@@ -148,12 +156,14 @@ func TestHabitUpdateToPresent(t *testing.T) {
 	})
 
 	t.Run("updates habit by 1 day", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.UpdateToPresent(1)
+		habit.UpdateToPresent(day, 1)
 
-		isUpdated := isUpdated(&habit, 1, 1, TotalTime{Hours: 2}, [HistoryLen]Entry{{}, {}, {}, {}, {}, {2, 2, false}})
+		isUpdated := isUpdated(&habit, 1, 1, TotalTime{Hours: 2}, []Entry{
+			{Date: day, StepsCount: 2, StepMinutes: 60, CheckedSteps: 2},
+		})
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
@@ -161,15 +171,15 @@ func TestHabitUpdateToPresent(t *testing.T) {
 	})
 
 	t.Run("updates frozen habit by 1 day", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.Summary.CurrentStreak = 2
-		habit.Summary.LongestStreak = 3
+		habit.Summary.Baseline.CurrentStreak = 2
+		habit.Summary.Baseline.LongestStreak = 3
 		habit.Freeze()
-		habit.UpdateToPresent(1)
+		habit.UpdateToPresent(day, 1)
 
-		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, [HistoryLen]Entry{{}, {}, {}, {}, {}, {0, 0, true}})
+		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, []Entry{{Date: day, IsFrozen: true}})
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
@@ -177,12 +187,16 @@ func TestHabitUpdateToPresent(t *testing.T) {
 	})
 
 	t.Run("updates habit by 3 days", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.UpdateToPresent(3)
+		habit.UpdateToPresent(day, 3)
 
-		isUpdated := isUpdated(&habit, 0, 1, TotalTime{Hours: 2}, [HistoryLen]Entry{{}, {}, {}, {2, 2, false}, {0, 2, false}, {0, 2, false}})
+		isUpdated := isUpdated(&habit, 0, 1, TotalTime{Hours: 2}, []Entry{
+			{Date: day, StepsCount: 2, StepMinutes: 60, CheckedSteps: 2},
+			{Date: dayAfter(1), StepsCount: 2, StepMinutes: 60},
+			{Date: dayAfter(2), StepsCount: 2, StepMinutes: 60},
+		})
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
@@ -190,28 +204,37 @@ func TestHabitUpdateToPresent(t *testing.T) {
 	})
 
 	t.Run("updates frozen habit by 3 days", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.Summary.CurrentStreak = 2
-		habit.Summary.LongestStreak = 3
+		habit.Summary.Baseline.CurrentStreak = 2
+		habit.Summary.Baseline.LongestStreak = 3
 		habit.Freeze()
-		habit.UpdateToPresent(3)
+		habit.UpdateToPresent(day, 3)
 
-		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, [HistoryLen]Entry{{}, {}, {}, {0, 0, true}, {0, 0, true}, {0, 0, true}})
+		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, []Entry{
+			{Date: day, IsFrozen: true},
+			{Date: dayAfter(1), IsFrozen: true},
+			{Date: dayAfter(2), IsFrozen: true},
+		})
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
 		}
 	})
 
-	t.Run("updates habit by 10 days", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+	t.Run("updates habit by 10 days and keeps the whole history", func(t *testing.T) {
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.UpdateToPresent(10)
+		habit.UpdateToPresent(day, 10)
+
+		history := []Entry{{Date: day, StepsCount: 2, StepMinutes: 60, CheckedSteps: 2}}
+		for i := 1; i < 10; i++ {
+			history = append(history, Entry{Date: dayAfter(i), StepsCount: 2, StepMinutes: 60})
+		}
 
-		isUpdated := isUpdated(&habit, 0, 1, TotalTime{Hours: 2}, [HistoryLen]Entry{{0, 2, false}, {0, 2, false}, {0, 2, false}, {0, 2, false}, {0, 2, false}, {0, 2, false}})
+		isUpdated := isUpdated(&habit, 0, 1, TotalTime{Hours: 2}, history)
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
@@ -219,18 +242,189 @@ func TestHabitUpdateToPresent(t *testing.T) {
 	})
 
 	t.Run("updates frozen habit by 10 days", func(t *testing.T) {
-		habit := newHabit("Test", 2, 60)
+		habit := newHabit("Test", 2, 60, day)
 		habit.CheckStep()
 		habit.CheckStep()
-		habit.Summary.CurrentStreak = 2
-		habit.Summary.LongestStreak = 3
+		habit.Summary.Baseline.CurrentStreak = 2
+		habit.Summary.Baseline.LongestStreak = 3
 		habit.Freeze()
-		habit.UpdateToPresent(10)
+		habit.UpdateToPresent(day, 10)
 
-		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, [HistoryLen]Entry{{0, 0, true}, {0, 0, true}, {0, 0, true}, {0, 0, true}, {0, 0, true}, {0, 0, true}})
+		history := []Entry{}
+		for i := range 10 {
+			history = append(history, Entry{Date: dayAfter(i), IsFrozen: true})
+		}
+
+		isUpdated := isUpdated(&habit, 2, 3, TotalTime{}, history)
 
 		if !isUpdated {
 			t.Error("habit has not been updated successfully")
 		}
 	})
+
+	t.Run("continues the streak over consecutive updates", func(t *testing.T) {
+		habit := newHabit("Test", 1, 30, day)
+		habit.CheckStep()
+		habit.UpdateToPresent(day, 1)
+		habit.CheckStep()
+		habit.UpdateToPresent(dayAfter(1), 1)
+
+		isUpdated := isUpdated(&habit, 2, 2, TotalTime{Hours: 1}, []Entry{
+			{Date: day, StepsCount: 1, StepMinutes: 30, CheckedSteps: 1},
+			{Date: dayAfter(1), StepsCount: 1, StepMinutes: 30, CheckedSteps: 1},
+		})
+
+		if !isUpdated {
+			t.Error("habit has not been updated successfully")
+		}
+	})
+}
+
+func TestScheduledUpdateToPresent(t *testing.T) {
+
+	t.Run("does not break the streak on days which are not scheduled", func(t *testing.T) {
+		// day is a Friday
+		habit := newHabit("Test", 1, 60, day)
+		habit.Schedule, _ = ParseSchedule("mon,fri", day)
+		habit.CheckStep()
+		habit.UpdateToPresent(day, 3)
+		habit.CheckStep()
+		habit.UpdateToPresent(dayAfter(3), 1)
+
+		isUpdated := isUpdated(&habit, 2, 2, TotalTime{Hours: 2}, []Entry{
+			{Date: day, StepsCount: 1, StepMinutes: 60, CheckedSteps: 1},
+			{Date: dayAfter(1), StepsCount: 1, StepMinutes: 60, IsOffDay: true},
+			{Date: dayAfter(2), StepsCount: 1, StepMinutes: 60, IsOffDay: true},
+			{Date: dayAfter(3), StepsCount: 1, StepMinutes: 60, CheckedSteps: 1},
+		})
+
+		if !isUpdated {
+			t.Errorf("habit has not been updated successfully: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("breaks the streak on a missed scheduled day", func(t *testing.T) {
+		habit := newHabit("Test", 1, 60, day)
+		habit.Schedule, _ = ParseSchedule("2d", day)
+		habit.CheckStep()
+		habit.UpdateToPresent(day, 3)
+
+		if habit.Summary.CurrentStreak != 0 || habit.Summary.LongestStreak != 1 {
+			t.Errorf("invalid streaks: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("keeps the streak when the weekly quota is met", func(t *testing.T) {
+		monday := dayAfter(3)
+		habit := newHabit("Test", 1, 60, day)
+		habit.Schedule, _ = ParseSchedule("2/w", day)
+
+		for i := range 7 {
+			if i == 1 || i == 4 {
+				habit.CheckStep()
+			}
+			habit.UpdateToPresent(monday.AddDate(0, 0, i), 1)
+		}
+
+		if habit.Summary.CurrentStreak != 2 {
+			t.Errorf("expected CurrentStreak to be %d, got %d", 2, habit.Summary.CurrentStreak)
+		}
+	})
+
+	t.Run("breaks the streak when the weekly quota is not met", func(t *testing.T) {
+		monday := dayAfter(3)
+		habit := newHabit("Test", 1, 60, day)
+		habit.Schedule, _ = ParseSchedule("3/w", day)
+
+		for i := range 7 {
+			if i == 1 || i == 4 {
+				habit.CheckStep()
+			}
+			habit.UpdateToPresent(monday.AddDate(0, 0, i), 1)
+		}
+
+		if habit.Summary.CurrentStreak != 0 || habit.Summary.LongestStreak != 1 {
+			t.Errorf("invalid streaks: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("does not break the streak before the end of the week", func(t *testing.T) {
+		monday := dayAfter(3)
+		habit := newHabit("Test", 1, 60, day)
+		habit.Schedule, _ = ParseSchedule("3/w", day)
+
+		for i := range 4 {
+			if i == 1 {
+				habit.CheckStep()
+			}
+			habit.UpdateToPresent(monday.AddDate(0, 0, i), 1)
+		}
+
+		if habit.Summary.CurrentStreak != 1 {
+			t.Errorf("expected CurrentStreak to be %d, got %d", 1, habit.Summary.CurrentStreak)
+		}
+	})
+}
+
+func TestLogDay(t *testing.T) {
+	// newLoggedHabit closes three days, the second one has been missed
+	newLoggedHabit := func() Habit {
+		habit := newHabit("Test", 1, 60, day)
+		for i := range 3 {
+			if i != 1 {
+				habit.CheckStep()
+			}
+			habit.UpdateToPresent(dayAfter(i), 1)
+		}
+
+		return habit
+	}
+
+	t.Run("recalculates the summary after an edit", func(t *testing.T) {
+		habit := newLoggedHabit()
+		habit.LogDay(dayAfter(1), 1)
+
+		if !isUpdated(&habit, 3, 3, TotalTime{Hours: 3}, habit.Summary.History) || habit.Summary.History[1].CheckedSteps != 1 {
+			t.Errorf("invalid summary after the edit: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("breaks the streak when a day is unchecked", func(t *testing.T) {
+		habit := newLoggedHabit()
+		habit.LogDay(dayAfter(1), 1)
+		habit.LogDay(dayAfter(2), 0)
+
+		if !isUpdated(&habit, 0, 2, TotalTime{Hours: 2}, habit.Summary.History) {
+			t.Errorf("invalid summary after the edit: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("adds a day before the history", func(t *testing.T) {
+		habit := newLoggedHabit()
+		habit.LogDay(dayAfter(-1), 1)
+
+		history := habit.Summary.History
+		if len(history) != 4 || history[0] != (Entry{Date: dayAfter(-1), StepsCount: 1, StepMinutes: 60, CheckedSteps: 1}) {
+			t.Errorf("day has not been added: %v", history)
+		}
+
+		if habit.Summary.LongestStreak != 2 || habit.Summary.TotalTime != (TotalTime{Hours: 3}) {
+			t.Errorf("invalid summary after the edit: %+v", habit.Summary)
+		}
+	})
+
+	t.Run("turns a frozen day into a regular one", func(t *testing.T) {
+		habit := newHabit("Test", 2, 30, day)
+		habit.Freeze()
+		habit.UpdateToPresent(day, 1)
+		habit.LogDay(day, 2)
+
+		if want := (Entry{Date: day, StepsCount: 2, StepMinutes: 30, CheckedSteps: 2}); habit.Summary.History[0] != want {
+			t.Errorf("expected %v, got %v", want, habit.Summary.History[0])
+		}
+
+		if habit.Summary.CurrentStreak != 1 {
+			t.Errorf("expected CurrentStreak to be %d, got %d", 1, habit.Summary.CurrentStreak)
+		}
+	})
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"slices"
 	"strconv"
 	"strings"
@@ -13,446 +12,556 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 
+	"github.com/seektor/habits-tracker-go/internal/clock"
 	"github.com/seektor/habits-tracker-go/internal/command"
+	"github.com/seektor/habits-tracker-go/internal/storage"
 	"github.com/seektor/habits-tracker-go/internal/utils"
 )
 
 type Habits struct {
-	Habits    []Habit
-	UpdatedAt time.Time
+	Version     int `json:"version"`
+	Habits      []Habit
+	NextID      int
+	UpdatedAt   time.Time
+	TimeZone    string   // IANA name of the time zone in which the days are counted
+	DayStartsAt DayStart // wall-clock time at which the days begin
+	EventSeq    int      // number of the last event included in the habits
+	path        string
+	store       Store
+	revision    string // of the habits which have been loaded or saved
+	clock       clock.Clock
+	location    *time.Location
+	commands    *command.Registry
+	running     string  // command which is being executed, recorded for undo
+	pending     []Event // events which have not been appended to the log yet
+
+	// Confirm asks the user a yes/no question, e.g. before a deletion. Nothing is asked when it is nil.
+	Confirm func(question string) bool `json:"-"`
 }
 
-func NewHabits() *Habits {
-	return &Habits{
+func NewHabits(clk clock.Clock) *Habits {
+	h := &Habits{
+		Version:   CurrentVersion,
 		Habits:    make([]Habit, 0),
-		UpdatedAt: time.Now(),
+		NextID:    1,
+		UpdatedAt: clk.Now(),
+		TimeZone:  utils.GetLocalTimeZone(),
+		clock:     clk,
+		location:  time.Local,
 	}
-}
 
-func (h *Habits) Load() error {
-	file, err := os.ReadFile(utils.FileName)
+	if location, err := time.LoadLocation(h.TimeZone); err == nil {
+		h.location = location
+	}
 
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
+	h.commands = h.newCommands()
 
-		return err
+	return h
+}
+
+// Load reads the habits from the data file, a missing file is treated as an empty one.
+// The store is chosen by the extension of the file, see OpenStore.
+// The path is used by the commands to save the changes.
+func (h *Habits) Load(path string) error {
+	if h.store != nil {
+		h.store.Close()
 	}
 
-	if len(file) == 0 {
+	h.path, h.store = path, OpenStore(path)
+
+	return h.load()
+}
+
+// load reads the habits from the store and replays the events which are missing in it.
+func (h *Habits) load() error {
+	h.EventSeq, h.pending = 0, nil
+	revision, err := h.store.Load(h)
+
+	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(file, h)
+	h.revision = revision
+	logged, err := h.replayTail()
 
 	if err != nil {
 		return err
 	}
 
+	if logged == 0 || logged < h.EventSeq {
+		// The log is missing or behind the data file, it is started again from the current state
+		h.appendCheckpoint()
+	}
+
 	return nil
 }
 
-func (h *Habits) Save(filename string) error {
-	data, err := json.Marshal(h)
+// Close releases the store of the habits.
+func (h *Habits) Close() error {
+	if h.store == nil {
+		return nil
+	}
+
+	return h.store.Close()
+}
+
+// decode reads the habits from the content of a data file, migrating it when needed.
+func (h *Habits) decode(data []byte) error {
+	migrated, _, err := migrate(data)
 
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	if err := json.Unmarshal(migrated, h); err != nil {
+		return err
+	}
+
+	if h.location, err = time.LoadLocation(h.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", h.TimeZone, err)
+	}
+
+	for idx := range h.Habits {
+		h.Habits[idx].recalculate()
+	}
+
+	return nil
 }
 
-func (h *Habits) Create(name string, stepsCount int8, stepTime int16) error {
-	if len(name) > int(MaxHabitNameLength) {
-		return fmt.Errorf("max habit name length cannot exceed %d", MaxHabitNameLength)
+func (h *Habits) Path() string {
+	return h.path
+}
+
+// now returns the current time in the time zone of the habits.
+func (h *Habits) now() time.Time {
+	return h.clock.Now().In(h.location)
+}
+
+// dayStart returns the day start of the habit, the one of the data file when the habit is nil
+// or does not override it.
+func (h *Habits) dayStart(habit *Habit) DayStart {
+	if habit != nil && habit.DayStartsAt != nil {
+		return *habit.DayStartsAt
 	}
 
-	err := validateStepData(stepsCount, stepTime)
+	return h.DayStartsAt
+}
+
+// today returns the date of the current day of the habit.
+func (h *Habits) today(habit *Habit) time.Time {
+	return utils.GetDayDate(h.clock.Now(), h.location, int(h.dayStart(habit)))
+}
+
+func parseTimeZone(name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+
+	if err != nil || name == "" {
+		return nil, fmt.Errorf("unknown time zone %q, expected an IANA name, e.g. Europe/Warsaw", name)
+	}
+
+	return location, nil
+}
+
+// SetTimeZone changes the time zone in which the days are counted.
+func (h *Habits) SetTimeZone(name string) error {
+	location, err := parseTimeZone(name)
 
 	if err != nil {
 		return err
 	}
 
-	habit := newHabit(name, stepsCount, stepTime)
-	h.Habits = append(h.Habits, habit)
+	h.TimeZone, h.location = name, location
 
 	return nil
 }
 
-func (h *Habits) Get(idx int) (*Habit, error) {
-	if idx >= 0 && idx < len(h.Habits) {
-		return &h.Habits[idx], nil
+// Save appends the new events to the log and writes the habits to the store of the path.
+func (h *Habits) Save(path string) error {
+	if path == "" {
+		return errors.New("data file has not been set")
 	}
 
-	return nil, errors.New("invalid index")
-}
+	store := h.store
+	if store == nil || path != h.path {
+		store = OpenStore(path)
+		defer store.Close()
+	}
 
-func (h *Habits) Delete(idx int) error {
-	if idx < 0 || idx >= len(h.Habits) {
-		return errors.New("invalid index")
+	// The log is started again from a checkpoint every EventsPerCheckpoint events, so a load replays only its tail
+	rotate := h.EventSeq/EventsPerCheckpoint > (h.EventSeq-len(h.pending))/EventsPerCheckpoint
+	if rotate {
+		h.appendCheckpoint()
 	}
 
-	h.Habits = slices.Delete(h.Habits, idx, idx+1)
+	if err := writeEvents(path, h.pending); err != nil {
+		return fmt.Errorf("events have not been logged: %w", err)
+	}
+
+	if last := len(h.pending) - 1; rotate && h.pending[last].Type == EventCheckpoint {
+		if err := rotateEvents(path, h.pending[last]); err != nil {
+			return fmt.Errorf("event log has not been rotated: %w", err)
+		}
+	}
+
+	h.pending = nil
+	revision, err := store.Save(h)
+
+	if err != nil {
+		return err
+	}
+
+	if path == h.path {
+		h.revision = revision
+	}
 
 	return nil
 }
 
-func (h *Habits) Freeze() {
-	for _, item := range h.Habits {
-		item.Freeze()
-	}
+// replace takes over the habits of another instance, e.g. loaded from a backup.
+func (h *Habits) replace(other *Habits) {
+	path, store, clk, commands, running, confirm := h.path, h.store, h.clock, h.commands, h.running, h.Confirm
+	*h = *other
+	h.path, h.store, h.clock, h.commands, h.running, h.Confirm = path, store, clk, commands, running, confirm
 }
 
-func (h *Habits) Unfreeze() {
-	for _, item := range h.Habits {
-		item.Unfreeze()
+// sync reloads the habits when the data file has been changed by another process.
+func (h *Habits) sync() error {
+	if h.path == "" {
+		return nil
 	}
-}
 
-func (h *Habits) UpdateToPresent() bool {
-	now := time.Now()
-	daysDiff := utils.GetDaysDiff(h.UpdatedAt, now)
+	revision, err := h.store.Revision()
 
-	switch {
-	case daysDiff < 0:
-		utils.PrintlnError("Unknown error has occurred")
-		return false
-	case daysDiff == 0:
-		utils.PrintlnInfo("Nothing to update")
-		return false
-	case daysDiff == 1:
-		utils.PrintlnInfo("Updating: 1 day has passed")
-	default:
-		utils.PrintlnInfo(fmt.Sprintf("Updating: %d days have passed \n", daysDiff))
+	if err != nil {
+		return err
 	}
 
-	for idx := range h.Habits {
-		h.Habits[idx].UpdateToPresent(daysDiff)
+	if revision == h.revision {
+		return nil
 	}
 
-	h.UpdatedAt = now
-	return true
-}
+	reloaded := NewHabits(h.clock)
+	reloaded.path, reloaded.store = h.path, h.store
 
-func (h *Habits) Print(idx int) {
-	t := table.NewWriter()
+	if err := reloaded.load(); err != nil {
+		return fmt.Errorf("habits have been changed by another process and cannot be reloaded: %w", err)
+	}
 
-	t.SetStyle(table.StyleLight)
-	t.Style().Options.SeparateRows = true
+	h.replace(reloaded)
+	utils.PrintlnInfo("Habits have been changed by another process and have been reloaded")
 
-	t.AppendHeader(table.Row{"#", "Name", "Checked Steps", "S Count", "S Time (min)", "Curr Streak (D)", "Lon Streak (D)", "Total Time", "History"})
+	return nil
+}
 
-	habits := h.Habits
+// Refresh reloads the habits changed by another process, closes the days which have passed
+// and saves the result. It returns the number of the closed days.
+func (h *Habits) Refresh() (int32, error) {
+	var daysDiff int32
 
-	isSingle := idx >= 0 && idx < len(h.Habits)
-	if isSingle {
-		habits = habits[idx:1]
-	}
+	err := h.withLock(func() error {
+		if err := h.sync(); err != nil {
+			return err
+		}
 
-	for iIdx, item := range habits {
-		if isSingle {
-			iIdx = idx
+		daysDiff = h.UpdateToPresent()
+
+		if daysDiff > 0 {
+			return h.Save(h.path)
 		}
 
-		totalTime := item.Summary.TotalTime
-		totalTime.Add(item.StepMinutes * int16(item.CheckedSteps))
+		return nil
+	})
 
-		t.AppendRow(table.Row{iIdx,
-			item.Name,
-			text.AlignCenter.Apply(stringifyCheckedSteps(&item), 12),
-			text.AlignCenter.Apply(strconv.Itoa(int(item.StepsCount)), 6),
-			text.AlignCenter.Apply(strconv.Itoa(int(item.StepMinutes)), 12),
-			text.AlignCenter.Apply(strconv.Itoa(int(item.Summary.CurrentStreak)), 12),
-			text.AlignCenter.Apply(strconv.Itoa(int(item.Summary.LongestStreak)), 12),
-			text.AlignCenter.Apply(totalTime.Stringify(), 12),
-			stringifyHistory(&item),
-		})
+	return daysDiff, err
+}
+
+func (h *Habits) withLock(fn func() error) error {
+	lock, err := storage.Lock(h.path, storage.DefaultLockTimeout)
+
+	if err != nil {
+		return err
 	}
 
-	fmt.Println(t.Render())
-}
+	defer lock.Unlock()
 
-func (h *Habits) PrintAll() {
-	h.Print(-1)
+	return fn()
 }
 
-func stringifyCheckedSteps(h *Habit) string {
-	switch {
-	case h.IsFrozen:
-		return text.BgBlue.Sprint("FROZEN")
-	case h.CheckedSteps < h.StepsCount:
-		return text.FgRed.Sprintf("%d ❌", h.CheckedSteps)
-	case h.CheckedSteps == h.StepsCount:
-		return text.FgGreen.Sprintf("%d ✅", h.CheckedSteps)
-	default:
-		return text.FgYellow.Sprintf("%d 😎", h.CheckedSteps)
+// mutate applies a change made by a command on top of the latest saved habits and saves it.
+// The data file stays locked for the whole time, so changes of other processes are not lost.
+// The change is recorded, so it can be undone.
+func (h *Habits) mutate(successMsg string, change func() error) error {
+	if h.path == "" {
+		return errors.New("data file has not been set")
 	}
-}
 
-func stringifyHistory(h *Habit) string {
-	emptyBlock := "▁"
-	halfBlock := "▄"
-	fullBlock := "█"
+	return h.withLock(func() error {
+		if err := h.sync(); err != nil {
+			return err
+		}
 
-	var sb strings.Builder
-	history := append(h.Summary.History[:], h.getCurrentEntry())
+		h.UpdateToPresent()
+		saved := h.savepoint()
 
-	for idx, entry := range history {
-		if entry.IsFrozen {
-			sb.WriteString(utils.ColorString(utils.FgColors.Blue, halfBlock))
-		} else {
-			if entry.CheckedSteps == 0 {
-				sb.WriteString(emptyBlock)
-			} else if entry.CheckedSteps < entry.StepsCount {
-				sb.WriteString(halfBlock)
-			} else if entry.CheckedSteps == entry.StepsCount {
-				sb.WriteString(utils.ColorString(utils.FgColors.Green, fullBlock))
-			} else {
-				sb.WriteString(utils.ColorString(utils.FgColors.Yellow, fullBlock))
-			}
+		if err := change(); err != nil {
+			h.rollback(saved)
+			return err
 		}
 
-		if idx != len(history)-1 {
-			sb.WriteRune(' ')
+		if err := h.Save(h.path); err != nil {
+			return fmt.Errorf("changes have not been saved: %w", err)
 		}
-	}
 
-	return sb.String()
+		utils.PrintlnSuccess(successMsg)
+		h.record(saved.state)
+
+		return nil
+	})
 }
 
-var commands = []struct {
-	command string
-	args    string
-	desc    string
-}{{"p", "[index?]", "Print all habits / a habit"},
-	{"a", "[name] [stepsCount] [stepMinutes]", "Add a habit"},
-	{"d", "[index]", "Delete a habit"},
-	{"ct", "[index] [stepMinutes]", "Change step time in minutes of a habit"},
-	{"cs", "[index] [stepsCount]", "Change number of steps"},
-	{"f", "[index]?", "Freeze all habits / a habit"},
-	{"uf", "[index]?", "Unfreeze all habits / a habit"},
-	{"q", "", "Quit"},
+func (h *Habits) Create(name string, stepsCount int8, stepTime int16, schedule Schedule) error {
+	return h.create(name, stepsCount, stepTime, schedule, h.now())
 }
 
-func (h *Habits) PrintCommands() {
-	t := table.NewWriter()
-	t.Style().Options.DrawBorder = false
-	t.Style().Options.SeparateColumns = false
+func (h *Habits) create(name string, stepsCount int8, stepTime int16, schedule Schedule, createdAt time.Time) error {
+	if len(name) > int(MaxHabitNameLength) {
+		return fmt.Errorf("max habit name length cannot exceed %d", MaxHabitNameLength)
+	}
 
-	for _, item := range commands {
+	if _, err := strconv.Atoi(name); err == nil || strings.Contains(name, ",") {
+		return errors.New("habit name cannot be a number or contain a comma")
+	}
 
-		t.AppendRow(table.Row{text.Bold.Sprint(item.command),
-			text.Bold.Sprint(item.args),
-			item.desc,
-		})
+	if slices.ContainsFunc(h.Habits, func(item Habit) bool { return strings.EqualFold(item.Name, name) }) {
+		return fmt.Errorf("habit %q already exists", name)
 	}
 
-	fmt.Println(t.Render())
-}
+	err := validateStepData(stepsCount, stepTime)
+
+	if err != nil {
+		return err
+	}
 
-func (h *Habits) Execute(command command.Command) {
-	switch command.Command {
-	case "p":
-		idxStr, idxStrErr := command.GetArg(0)
+	habit := newHabit(name, stepsCount, stepTime, createdAt)
+	habit.ID = h.NextID
+	habit.Schedule = schedule
+	h.Habits = append(h.Habits, habit)
+	h.NextID += 1
 
-		if idxStrErr != nil {
-			h.PrintAll()
-		} else {
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				utils.PrintlnError("invalid index")
-				return
-			}
-			h.Print(int(idx))
-		}
+	return nil
+}
 
-	case "a":
-		name, nameErr := command.GetArg(0)
-		stepsCountStr, stepsCountStrErr := command.GetArg(2)
-		stepMinutesStr, stepMinutesStrErr := command.GetArg(1)
+// indexOf resolves a habit reference, which is an ID, an exact name or an unambiguous name prefix.
+func (h *Habits) indexOf(ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.ID == id })
 
-		if nameErr != nil || stepsCountStrErr != nil || stepMinutesStrErr != nil {
-			utils.PrintlnError("missing arguments")
-			return
+		if idx < 0 {
+			return -1, fmt.Errorf("habit with id %d does not exist", id)
 		}
 
-		stepsCount, stepsCountErr := strconv.Atoi(stepsCountStr)
-		stepMinutes, stepMinutesErr := strconv.Atoi(stepMinutesStr)
+		return idx, nil
+	}
+
+	if idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.Name == ref }); idx >= 0 {
+		return idx, nil
+	}
 
-		if stepsCountErr != nil || stepMinutesErr != nil {
-			utils.PrintlnError("stepsCount and stepMinutes have to be a number within a proper range")
-			return
+	matchIdx := -1
+	for idx, item := range h.Habits {
+		if !strings.HasPrefix(strings.ToLower(item.Name), strings.ToLower(ref)) {
+			continue
 		}
 
-		err := h.Create(name, int8(stepMinutes), int16(stepsCount))
-		if err != nil {
-			utils.PrintlnError(err.Error())
-			return
+		if matchIdx >= 0 {
+			return -1, fmt.Errorf("%q matches more than one habit", ref)
 		}
 
-		utils.PrintlnSuccess("Habit has been created")
-		h.Save(utils.FileName)
+		matchIdx = idx
+	}
 
-	case "d":
-		idxStr, idxStrErr := command.GetArg(0)
+	if matchIdx < 0 {
+		return -1, fmt.Errorf("habit %q does not exist", ref)
+	}
 
-		if idxStrErr != nil {
-			utils.PrintlnError("missing argument")
-			return
-		}
+	return matchIdx, nil
+}
 
-		idx, idxErr := strconv.Atoi(idxStr)
+func (h *Habits) Get(ref string) (*Habit, error) {
+	idx, err := h.indexOf(ref)
 
-		if idxErr != nil {
-			utils.PrintlnError("invalid index")
-			return
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		err := h.Delete(idx)
+	return &h.Habits[idx], nil
+}
 
-		if err == nil {
-			utils.PrintlnSuccess("Habit has been deleted")
-			h.Save(utils.FileName)
-		} else {
-			utils.PrintlnError(err.Error())
-		}
+// getMany resolves a comma separated list of habit references, e.g. "1,3,read".
+func (h *Habits) getMany(refList string) ([]*Habit, error) {
+	selected := []*Habit{}
 
-	case "ct":
-		idxStr, idxStrErr := command.GetArg(0)
-		stepMinutesStr, stepMinutesStrErr := command.GetArg(1)
+	for _, ref := range strings.Split(refList, ",") {
+		habit, err := h.Get(ref)
 
-		if idxStrErr != nil || stepMinutesStrErr != nil {
-			utils.PrintlnError("missing arguments")
-			return
+		if err != nil {
+			return nil, err
 		}
 
-		idx, idxErr := strconv.Atoi(idxStr)
-		stepMinutes, stepMinutesErr := strconv.Atoi(stepMinutesStr)
-
-		if idxErr != nil {
-			utils.PrintlnError("invalid index")
-			return
+		if !slices.Contains(selected, habit) {
+			selected = append(selected, habit)
 		}
+	}
 
-		if stepMinutesErr != nil {
-			utils.PrintlnError("invalid number of minutes")
-			return
-		}
+	return selected, nil
+}
 
-		habit, habitErr := h.Get(idx)
+func (h *Habits) Delete(ref string) error {
+	idx, err := h.indexOf(ref)
 
-		if habitErr != nil {
-			utils.PrintlnError(habitErr.Error())
-			return
-		}
+	if err != nil {
+		return err
+	}
 
-		err := habit.SetStepMinutes(int16(stepMinutes))
+	h.Habits = slices.Delete(h.Habits, idx, idx+1)
 
-		if err == nil {
-			utils.PrintlnSuccess("Step time has been updated")
-			h.Save(utils.FileName)
-		} else {
-			utils.PrintlnError(err.Error())
-		}
+	return nil
+}
 
-	case "cs":
-		idxStr, idxStrErr := command.GetArg(0)
-		stepsCountStr, stepsCountStrErr := command.GetArg(1)
+func (h *Habits) Freeze() {
+	for idx := range h.Habits {
+		h.Habits[idx].Freeze()
+	}
+}
 
-		if idxStrErr != nil || stepsCountStrErr != nil {
-			utils.PrintlnError("missing arguments")
-			return
-		}
+func (h *Habits) Unfreeze() {
+	for idx := range h.Habits {
+		h.Habits[idx].Unfreeze()
+	}
+}
 
-		idx, idxErr := strconv.Atoi(idxStr)
-		stepsCount, stepsCountErr := strconv.Atoi(stepsCountStr)
+// UpdateToPresent closes the days which have passed since the last update and returns their number.
+// A negative number means that the last update is in the future and nothing has been changed.
+func (h *Habits) UpdateToPresent() int32 {
+	now := h.now()
+	daysDiff := h.closeDays(now)
 
-		if idxErr != nil {
-			utils.PrintlnError("invalid index")
-			return
-		}
+	if daysDiff > 0 {
+		h.appendEvent(Event{At: now, Type: EventDaysClosed})
+	}
 
-		if stepsCountErr != nil {
-			utils.PrintlnError("invalid number of steps")
-			return
-		}
+	return daysDiff
+}
 
-		habit, habitErr := h.Get(idx)
+// closeDays closes the days which have passed between the last update and now.
+// The days of a habit with its own day start may be closed before the days of the data file.
+func (h *Habits) closeDays(now time.Time) int32 {
+	daysDiff := utils.GetDaysDiffIn(h.UpdatedAt, now, h.location, int(h.DayStartsAt))
 
-		if habitErr != nil {
-			utils.PrintlnError(habitErr.Error())
-			return
-		}
+	if daysDiff < 0 {
+		return daysDiff
+	}
 
-		err := habit.SetStepsCount(int8(stepsCount))
+	for idx := range h.Habits {
+		dayStart := int(h.dayStart(&h.Habits[idx]))
+		habitDaysDiff := utils.GetDaysDiffIn(h.UpdatedAt, now, h.location, dayStart)
 
-		if err == nil {
-			utils.PrintlnSuccess("Steps count has been updated")
-			h.Save(utils.FileName)
-		} else {
-			utils.PrintlnError(err.Error())
-		}
+		h.Habits[idx].UpdateToPresent(utils.GetDayDate(h.UpdatedAt, h.location, dayStart), habitDaysDiff)
+		daysDiff = max(daysDiff, habitDaysDiff)
+	}
 
-	case "f":
-		idxStr, idxStrErr := command.GetArg(0)
+	if daysDiff > 0 {
+		h.UpdatedAt = now
+	}
 
-		if idxStrErr != nil {
-			h.Freeze()
-			utils.PrintlnSuccess("Habits have been frozen")
-			h.Save(utils.FileName)
-		} else {
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				utils.PrintlnError("invalid index")
-				return
-			}
+	return daysDiff
+}
 
-			habit, habitErr := h.Get(idx)
+func (h *Habits) Print(habits ...Habit) {
+	fmt.Println(h.renderTable(habits...))
+}
 
-			if habitErr != nil {
-				utils.PrintlnError(habitErr.Error())
-				return
-			}
-			habit.Freeze()
-			utils.PrintlnSuccess("Habit has been frozen")
-			h.Save(utils.FileName)
-		}
+func (h *Habits) renderTable(habits ...Habit) string {
+	t := table.NewWriter()
 
-	case "uf":
-		idxStr, idxStrErr := command.GetArg(0)
+	t.SetStyle(table.StyleLight)
+	t.Style().Options.SeparateRows = true
 
-		if idxStrErr != nil {
-			h.Unfreeze()
-			utils.PrintlnSuccess("Habits have been unfrozen")
-			h.Save(utils.FileName)
-		} else {
-			idx, err := strconv.Atoi(idxStr)
-			if err != nil {
-				utils.PrintlnError("invalid index")
-				return
-			}
+	t.AppendHeader(table.Row{"#", "Name", "Checked Steps", "S Count", "S Time (min)", "Schedule", "Curr Streak (D)", "Lon Streak (D)", "Total Time", "History"})
 
-			habit, habitErr := h.Get(idx)
+	for _, item := range habits {
+		today := h.today(&item)
+		totalTime := item.Summary.TotalTime
+		totalTime.Add(int(item.StepMinutes) * int(item.CheckedSteps))
 
-			if habitErr != nil {
-				utils.PrintlnError(habitErr.Error())
-				return
-			}
-			habit.Freeze()
-			utils.PrintlnSuccess("Habit has been unfrozen")
-			h.Save(utils.FileName)
-		}
+		t.AppendRow(table.Row{item.ID,
+			item.Name,
+			text.AlignCenter.Apply(stringifyCheckedSteps(&item, today), 12),
+			text.AlignCenter.Apply(strconv.Itoa(int(item.StepsCount)), 6),
+			text.AlignCenter.Apply(strconv.Itoa(int(item.StepMinutes)), 12),
+			text.AlignCenter.Apply(item.Schedule.String(), 8),
+			text.AlignCenter.Apply(strconv.Itoa(int(item.Summary.CurrentStreak)), 12),
+			text.AlignCenter.Apply(strconv.Itoa(int(item.Summary.LongestStreak)), 12),
+			text.AlignCenter.Apply(totalTime.Stringify(), 12),
+			stringifyHistory(&item, today),
+		})
+	}
+
+	return t.Render()
+}
 
-	case "q":
-		utils.PrintlnSuccess("Bye bye")
-		os.Exit(0)
+func (h *Habits) PrintAll() {
+	h.Print(h.Habits...)
+}
 
+func stringifyCheckedSteps(h *Habit, today time.Time) string {
+	switch {
+	case h.IsFrozen:
+		return text.BgBlue.Sprint("FROZEN")
+	case h.CheckedSteps == 0 && !h.Schedule.IsDue(today):
+		return text.Faint.Sprint("OFF DAY")
+	case h.CheckedSteps < h.StepsCount:
+		return text.FgRed.Sprintf("%d ❌", h.CheckedSteps)
+	case h.CheckedSteps == h.StepsCount:
+		return text.FgGreen.Sprintf("%d ✅", h.CheckedSteps)
 	default:
-		fmt.Println()
-		utils.PrintlnError("unknown command")
-		fmt.Println()
-		h.PrintCommands()
+		return text.FgYellow.Sprintf("%d 😎", h.CheckedSteps)
 	}
+}
+
+func stringifyHistory(h *Habit, today time.Time) string {
+	emptyBlock := "▁"
+	halfBlock := "▄"
+	fullBlock := "█"
+	offDayBlock := "·"
 
+	var sb strings.Builder
+	history := make([]Entry, HistoryLen, HistoryLen+1)
+	pastEntries := h.Summary.History[max(0, len(h.Summary.History)-int(HistoryLen)):]
+	copy(history[int(HistoryLen)-len(pastEntries):], pastEntries)
+	history = append(history, h.getCurrentEntry(today))
+
+	for idx, entry := range history {
+		if entry.IsFrozen {
+			sb.WriteString(utils.ColorString(utils.FgColors.Blue, halfBlock))
+		} else if entry.IsOffDay && entry.CheckedSteps == 0 {
+			sb.WriteString(offDayBlock)
+		} else {
+			if entry.CheckedSteps == 0 {
+				sb.WriteString(emptyBlock)
+			} else if entry.CheckedSteps < entry.StepsCount {
+				sb.WriteString(halfBlock)
+			} else if entry.CheckedSteps == entry.StepsCount {
+				sb.WriteString(utils.ColorString(utils.FgColors.Green, fullBlock))
+			} else {
+				sb.WriteString(utils.ColorString(utils.FgColors.Yellow, fullBlock))
+			}
+		}
+
+		if idx != len(history)-1 {
+			sb.WriteRune(' ')
+		}
+	}
+
+	return sb.String()
 }
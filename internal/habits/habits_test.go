@@ -1,17 +1,35 @@
 package habits
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+	_ "time/tzdata"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
 )
 
+// newTestHabits creates habits saved in a temporary directory.
+func newTestHabits(t *testing.T) *Habits {
+	habits := NewHabits(clock.NewFake(day))
+
+	if err := habits.Load(filepath.Join(t.TempDir(), "habits.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	return habits
+}
+
 func TestCreate(t *testing.T) {
 
 	t.Run("creates a habit", func(t *testing.T) {
-		habits := NewHabits()
-		res := habits.Create("Test", 1, 60)
+		habits := NewHabits(clock.NewFake(day))
+		res := habits.Create("Test", 1, 60, Schedule{})
 
 		if len(habits.Habits) != 1 {
 			t.Error("expected habits length to be 1, got 0")
@@ -23,8 +41,8 @@ func TestCreate(t *testing.T) {
 	})
 
 	t.Run(fmt.Sprintf("returns an error when a name is longer than %d", MaxHabitNameLength), func(t *testing.T) {
-		habits := NewHabits()
-		res := habits.Create(strings.Repeat("A", int(MaxHabitNameLength)+1), 1, 60)
+		habits := NewHabits(clock.NewFake(day))
+		res := habits.Create(strings.Repeat("A", int(MaxHabitNameLength)+1), 1, 60, Schedule{})
 
 		if res == nil {
 			t.Error("expected an error")
@@ -32,17 +50,28 @@ func TestCreate(t *testing.T) {
 	})
 
 	t.Run("returns an error when the StepCount or StepTime are smaller than 1", func(t *testing.T) {
-		habits := NewHabits()
-		res := habits.Create("Test", -1, 0)
+		habits := NewHabits(clock.NewFake(day))
+		res := habits.Create("Test", -1, 0, Schedule{})
 
 		if res == nil {
 			t.Error("expected an error")
 		}
 	})
 
+	t.Run("returns an error when the name already exists or is a number", func(t *testing.T) {
+		habits := NewHabits(clock.NewFake(day))
+		habits.Create("Test", 1, 60, Schedule{})
+
+		for _, name := range []string{"test", "12", "a,b"} {
+			if habits.Create(name, 1, 60, Schedule{}) == nil {
+				t.Errorf("expected an error for %q", name)
+			}
+		}
+	})
+
 	t.Run(fmt.Sprintf("returns an error when the total habit time is longer than %d", MaxHabitTotalTime), func(t *testing.T) {
-		habits := NewHabits()
-		res := habits.Create("Test", 17, 60)
+		habits := NewHabits(clock.NewFake(day))
+		res := habits.Create("Test", 17, 60, Schedule{})
 
 		if res == nil {
 			t.Error("expected an error")
@@ -50,12 +79,92 @@ func TestCreate(t *testing.T) {
 	})
 }
 
+func TestExecute(t *testing.T) {
+
+	t.Run("returns a usage error for an unknown command", func(t *testing.T) {
+		habits := NewHabits(clock.NewFake(day))
+		err := habits.Execute(command.NewCommand("unknown"))
+
+		if !errors.Is(err, command.ErrUnknownCommand) {
+			t.Errorf("expected an unknown command error, got %v", err)
+		}
+	})
+
+	t.Run("returns a usage error for missing arguments", func(t *testing.T) {
+		habits := NewHabits(clock.NewFake(day))
+		err := habits.Execute(command.NewCommand("a Test 1"))
+
+		var usageErr command.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+
+	t.Run("returns an error for an unknown habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		err := habits.Execute(command.NewCommand("check read"))
+
+		var usageErr command.UsageError
+		if err == nil || errors.As(err, &usageErr) {
+			t.Errorf("expected an error which is not a usage error, got %v", err)
+		}
+	})
+
+	t.Run("resolves the long name of a command", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Reading", 1, 30, Schedule{})
+		err := habits.Execute(command.NewCommandFromArgs([]string{"check", "read", "2"}))
+
+		if err != nil || habits.Habits[0].CheckedSteps != 2 {
+			t.Errorf("expected CheckedSteps to be %d, got %d (%v)", 2, habits.Habits[0].CheckedSteps, err)
+		}
+	})
+
+	t.Run("freezes and unfreezes all habits", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("First", 1, 30, Schedule{})
+		habits.Create("Second", 1, 30, Schedule{})
+		habits.Execute(command.NewCommand("f"))
+
+		if !habits.Habits[0].IsFrozen || !habits.Habits[1].IsFrozen {
+			t.Error("habits have not been frozen")
+		}
+
+		habits.Execute(command.NewCommand("uf 2"))
+
+		if !habits.Habits[0].IsFrozen || habits.Habits[1].IsFrozen {
+			t.Error("habit has not been unfrozen")
+		}
+	})
+}
+
+func TestExecuteAdd(t *testing.T) {
+
+	t.Run("adds a habit with a schedule", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 2 30 mon,fri"))
+
+		if len(habits.Habits) != 1 || habits.Habits[0].Schedule.String() != "mon,fri" {
+			t.Errorf("habit has not been created with a schedule: %+v", habits.Habits)
+		}
+	})
+
+	t.Run("does not add a habit with an invalid schedule", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 2 30 someday"))
+
+		if len(habits.Habits) != 0 {
+			t.Errorf("expected habits length to be 0, got %d", len(habits.Habits))
+		}
+	})
+}
+
 func TestDelete(t *testing.T) {
 
 	t.Run("deletes a habit by index", func(t *testing.T) {
-		habits := NewHabits()
-		habits.Create("Test", 1, 60)
-		res := habits.Delete(0)
+		habits := NewHabits(clock.NewFake(day))
+		habits.Create("Test", 1, 60, Schedule{})
+		res := habits.Delete("1")
 
 		if len(habits.Habits) != 0 {
 			t.Errorf("expected habits length to be 0, got %d", len(habits.Habits))
@@ -67,9 +176,9 @@ func TestDelete(t *testing.T) {
 	})
 
 	t.Run("returns an error when the index is out of range", func(t *testing.T) {
-		habits := NewHabits()
-		habits.Create("Test", 1, 60)
-		res := habits.Delete(1)
+		habits := NewHabits(clock.NewFake(day))
+		habits.Create("Test", 1, 60, Schedule{})
+		res := habits.Delete("2")
 
 		if res == nil {
 			t.Error("expected an error")
@@ -77,48 +186,685 @@ func TestDelete(t *testing.T) {
 	})
 }
 
+func TestLoad(t *testing.T) {
+
+	t.Run("treats a missing file as empty and saves to its path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "profiles", "work.json")
+		habits := NewHabits(clock.NewFake(day))
+
+		if err := habits.Load(path); err != nil || len(habits.Habits) != 0 {
+			t.Fatalf("expected no habits, got %d (%v)", len(habits.Habits), err)
+		}
+
+		habits.Execute(command.NewCommand("a Test 1 30"))
+
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("habits have not been saved to %s: %v", path, err)
+		}
+	})
+
+	t.Run("migrates the fixed six-day history of older files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		legacy := `{"Habits":[{"Name":"Test","StepsCount":1,"StepMinutes":30,"CheckedSteps":0,"IsFrozen":false,` +
+			`"Summary":{"TotalTime":{"Days":0,"Hours":5,"Minutes":0},"LongestStreak":7,"CurrentStreak":3,` +
+			`"History":[{"CheckedSteps":1,"StepsCount":1,"IsFrozen":false},{"CheckedSteps":1,"StepsCount":1,"IsFrozen":false},` +
+			`{"CheckedSteps":0,"StepsCount":1,"IsFrozen":false},{"CheckedSteps":1,"StepsCount":1,"IsFrozen":false},` +
+			`{"CheckedSteps":0,"StepsCount":0,"IsFrozen":true},{"CheckedSteps":2,"StepsCount":1,"IsFrozen":false}]}}],` +
+			`"UpdatedAt":"2020-11-20T10:00:00Z"}`
+		os.WriteFile(path, []byte(legacy), 0644)
+
+		habits := NewHabits(clock.NewFake(day))
+		if err := habits.Load(path); err != nil {
+			t.Fatal(err)
+		}
+
+		if habits.Habits[0].ID != 1 || habits.NextID != 2 {
+			t.Errorf("IDs have not been assigned, got %d, next %d", habits.Habits[0].ID, habits.NextID)
+		}
+
+		summary := habits.Habits[0].Summary
+		if len(summary.History) != 6 || summary.History[5].Date != time.Date(2020, 11, 19, 0, 0, 0, 0, time.UTC) {
+			t.Errorf("history has not been migrated: %v", summary.History)
+		}
+
+		if summary.CurrentStreak != 2 || summary.LongestStreak != 7 || summary.TotalTime != (TotalTime{Hours: 5}) {
+			t.Errorf("summary has not been kept: %+v", summary)
+		}
+	})
+
+	t.Run("backs up the file before the migration", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		original := `{"Habits":[],"UpdatedAt":"2020-11-20T10:00:00Z"}`
+		os.WriteFile(path, []byte(original), 0644)
+
+		habits := NewHabits(clock.NewFake(day))
+		if err := habits.Load(path); err != nil {
+			t.Fatal(err)
+		}
+
+		backup, err := os.ReadFile(filepath.Join(filepath.Dir(path), "backups", "habits.v0.json"))
+		if err != nil || string(backup) != original {
+			t.Errorf("original file has not been backed up, got %q (%v)", backup, err)
+		}
+
+		if habits.Version != CurrentVersion {
+			t.Errorf("expected version %d, got %d", CurrentVersion, habits.Version)
+		}
+	})
+
+	t.Run("refuses a file of a newer version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		os.WriteFile(path, []byte(fmt.Sprintf(`{"version":%d,"Habits":[]}`, CurrentVersion+1)), 0644)
+
+		if err := NewHabits(clock.NewFake(day)).Load(path); err == nil {
+			t.Error("expected an error for a newer version")
+		}
+	})
+}
+
+func TestGet(t *testing.T) {
+	habits := NewHabits(clock.NewFake(day))
+	habits.Create("Reading", 1, 60, Schedule{})
+	habits.Create("Running", 1, 60, Schedule{})
+	habits.Create("Run", 1, 60, Schedule{})
+	habits.Create("Guitar", 1, 60, Schedule{})
+
+	var tests = []struct {
+		ref  string
+		want string
+	}{
+		{"2", "Running"},
+		{"Run", "Run"},
+		{"rea", "Reading"},
+		{"g", "Guitar"},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("resolves a habit reference", func(t *testing.T) {
+			habit, err := habits.Get(tt.ref)
+
+			if err != nil || habit.Name != tt.want {
+				t.Errorf("expected %q to resolve to %s, got %v (%v)", tt.ref, tt.want, habit, err)
+			}
+		})
+	}
+
+	for _, ref := range []string{"5", "r", "Swimming"} {
+
+		t.Run("returns an error for an unknown or ambiguous reference", func(t *testing.T) {
+			_, err := habits.Get(ref)
+
+			if err == nil {
+				t.Errorf("expected an error for %q", ref)
+			}
+		})
+	}
+
+	t.Run("keeps the IDs after a delete", func(t *testing.T) {
+		habits := NewHabits(clock.NewFake(day))
+		habits.Create("First", 1, 60, Schedule{})
+		habits.Create("Second", 1, 60, Schedule{})
+		habits.Delete("1")
+		habits.Create("Third", 1, 60, Schedule{})
+
+		second, _ := habits.Get("2")
+		third, _ := habits.Get("3")
+
+		if second == nil || second.Name != "Second" || third == nil || third.Name != "Third" {
+			t.Error("IDs have changed after a delete")
+		}
+	})
+}
+
+func TestSave(t *testing.T) {
+
+	t.Run("reports a failed save", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "file"), []byte{}, 0644)
+
+		habits := NewHabits(clock.NewFake(day))
+		habits.Load(filepath.Join(dir, "file", "habits.json"))
+		err := habits.Execute(command.NewCommand("a Test 1 30"))
+
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("restores habits from a backup", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a First 1 30"))
+		habits.Execute(command.NewCommand("a Second 1 30"))
+		habits.Execute(command.NewCommand("d first"))
+
+		if err := habits.Execute(command.NewCommand("restore 1")); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded := NewHabits(clock.NewFake(day))
+		loaded.Load(habits.Path())
+
+		if len(habits.Habits) != 2 || len(loaded.Habits) != 2 {
+			t.Errorf("expected %d habits, got %d, saved %d", 2, len(habits.Habits), len(loaded.Habits))
+		}
+	})
+}
+
+func TestConcurrentInstances(t *testing.T) {
+
+	t.Run("keeps the check-ins of both instances", func(t *testing.T) {
+		first := newTestHabits(t)
+		first.Execute(command.NewCommand("a Test 5 30"))
+
+		second := NewHabits(clock.NewFake(day))
+		second.Load(first.Path())
+
+		first.Execute(command.NewCommand("c test 2"))
+		second.Execute(command.NewCommand("c test 1"))
+
+		saved := NewHabits(clock.NewFake(day))
+		saved.Load(first.Path())
+
+		if second.Habits[0].CheckedSteps != 3 || saved.Habits[0].CheckedSteps != 3 {
+			t.Errorf("expected CheckedSteps to be %d, got %d, saved %d", 3, second.Habits[0].CheckedSteps, saved.Habits[0].CheckedSteps)
+		}
+	})
+
+	t.Run("gives unique IDs to habits created by both instances", func(t *testing.T) {
+		first := newTestHabits(t)
+		second := NewHabits(clock.NewFake(day))
+		second.Load(first.Path())
+
+		first.Execute(command.NewCommand("a First 1 30"))
+		second.Execute(command.NewCommand("a Second 1 30"))
+		first.Execute(command.NewCommand("c first"))
+
+		saved := NewHabits(clock.NewFake(day))
+		saved.Load(first.Path())
+
+		if len(saved.Habits) != 2 || saved.Habits[0].ID == saved.Habits[1].ID || saved.Habits[0].CheckedSteps != 1 {
+			t.Errorf("invalid saved habits: %+v", saved.Habits)
+		}
+	})
+
+	t.Run("refuses a change of a habit deleted by another instance", func(t *testing.T) {
+		first := newTestHabits(t)
+		first.Execute(command.NewCommand("a Test 1 30"))
+
+		second := NewHabits(clock.NewFake(day))
+		second.Load(first.Path())
+
+		first.Execute(command.NewCommand("d test"))
+		err := second.Execute(command.NewCommand("c test"))
+
+		if err == nil || len(second.Habits) != 0 {
+			t.Errorf("expected an error and no habits, got %v and %d habits", err, len(second.Habits))
+		}
+	})
+}
+
+// newHabitsAt creates habits which count the days in UTC with a fake clock.
+func newHabitsAt(fake *clock.Fake) *Habits {
+	habits := NewHabits(fake)
+	habits.SetTimeZone("UTC")
+
+	return habits
+}
+
+// habitSpec is a habit with its closed days up to yesterday, a character per day:
+// a digit for the checked steps, f for a frozen day and o for an off day.
+type habitSpec struct {
+	name         string
+	stepsCount   int8
+	stepMinutes  int16
+	schedule     Schedule
+	history      string
+	checkedSteps int8 // of the current day
+}
+
+// newHabitsWith creates the habits of the specs at noon of day, counting the days in UTC.
+func newHabitsWith(t *testing.T, specs ...habitSpec) *Habits {
+	habits := newHabitsAt(clock.NewFake(day.Add(12 * time.Hour)))
+
+	for _, spec := range specs {
+		if err := habits.Create(spec.name, spec.stepsCount, spec.stepMinutes, spec.schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		habit := &habits.Habits[len(habits.Habits)-1]
+		habit.CheckedSteps = spec.checkedSteps
+
+		for idx, kind := range spec.history {
+			entry := Entry{Date: dayAfter(idx - len(spec.history)), StepsCount: spec.stepsCount, StepMinutes: spec.stepMinutes, WeeklyTimes: spec.schedule.Times}
+
+			switch {
+			case kind == 'f':
+				entry = Entry{Date: entry.Date, IsFrozen: true}
+			case kind == 'o':
+				entry.IsOffDay = true
+			case kind >= '0' && kind <= '9':
+				entry.CheckedSteps = int8(kind - '0')
+			default:
+				t.Fatalf("invalid day %q in the history of %s", kind, spec.name)
+			}
+
+			habit.Summary.History = append(habit.Summary.History, entry)
+		}
+
+		habit.recalculate()
+	}
+
+	return habits
+}
+
 func TestHabitsUpdateToPresent(t *testing.T) {
+	morning := day.Add(8 * time.Hour)
 
 	t.Run("does not update habits when there is no day difference", func(t *testing.T) {
-		habits := NewHabits()
-		habits.Create("Test", 1, 60)
+		fake := clock.NewFake(morning)
+		habits := newHabitsAt(fake)
+		habits.Create("Test", 1, 60, Schedule{})
+		fake.Advance(12 * time.Hour)
 
-		isUpdated := habits.UpdateToPresent()
+		daysDiff := habits.UpdateToPresent()
+
+		if daysDiff != 0 || len(habits.Habits[0].Summary.History) != 0 {
+			t.Errorf("expected no update, got %d days", daysDiff)
+		}
 
-		if isUpdated {
-			t.Error("habits have not been updated")
+		if !habits.UpdatedAt.Equal(morning) {
+			t.Errorf("expected UpdatedAt to be %v, got %v", morning, habits.UpdatedAt)
 		}
+	})
+
+	t.Run("updates habits when there is a day difference", func(t *testing.T) {
+		fake := clock.NewFake(morning)
+		habits := newHabitsAt(fake)
+		habits.Create("Test", 1, 60, Schedule{})
+		habits.Habits[0].CheckStep()
+		fake.AdvanceDays(1)
+
+		daysDiff := habits.UpdateToPresent()
 
-		now := time.Now()
+		if daysDiff != 1 {
+			t.Errorf("expected %d day to be closed, got %d", 1, daysDiff)
+		}
 
-		isDateUpdated := habits.UpdatedAt.Year() == now.Year() &&
-			habits.UpdatedAt.Month() == now.Month() &&
-			habits.UpdatedAt.Day() == now.Day()
+		if !habits.UpdatedAt.Equal(fake.Now()) {
+			t.Errorf("expected UpdatedAt to be %v, got %v", fake.Now(), habits.UpdatedAt)
+		}
 
-		if !isDateUpdated {
-			t.Error("date have not been updated")
+		habit := habits.Habits[0]
+		if habit.CheckedSteps != 0 || habit.Summary.CurrentStreak != 1 || len(habit.Summary.History) != 1 {
+			t.Errorf("day has not been closed: %+v", habit)
 		}
 	})
 
-	t.Run("updates habits when there is a day difference", func(t *testing.T) {
-		habits := NewHabits()
-		habits.Create("Test", 1, 60)
-		habits.UpdatedAt = habits.UpdatedAt.AddDate(0, 0, -1)
+	t.Run("closes every day of a vacation", func(t *testing.T) {
+		fake := clock.NewFake(morning)
+		habits := newHabitsAt(fake)
+		habits.Create("Test", 1, 60, Schedule{})
+		habits.Habits[0].CheckStep()
+		fake.AdvanceDays(10)
+
+		daysDiff := habits.UpdateToPresent()
+
+		summary := habits.Habits[0].Summary
+		if daysDiff != 10 || len(summary.History) != 10 || summary.History[9].Date != dayAfter(9) {
+			t.Errorf("expected %d closed days, got %d and history %v", 10, daysDiff, summary.History)
+		}
+
+		if summary.CurrentStreak != 0 || summary.LongestStreak != 1 {
+			t.Errorf("expected the vacation to break the streak: %+v", summary)
+		}
+	})
+
+	t.Run("does not change habits when the clock is behind the last update", func(t *testing.T) {
+		fake := clock.NewFake(morning)
+		habits := newHabitsAt(fake)
+		habits.Create("Test", 1, 60, Schedule{})
+		fake.AdvanceDays(-2)
+
+		daysDiff := habits.UpdateToPresent()
+
+		if daysDiff != -2 || !habits.UpdatedAt.Equal(morning) || len(habits.Habits[0].Summary.History) != 0 {
+			t.Errorf("expected no change and %d days, got %d", -2, daysDiff)
+		}
+	})
+
+	t.Run("saves the closed days on refresh", func(t *testing.T) {
+		fake := clock.NewFake(morning)
+		path := filepath.Join(t.TempDir(), "habits.json")
+		habits := newHabitsAt(fake)
+		habits.Load(path)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		fake.AdvanceDays(3)
+
+		if daysDiff, err := habits.Refresh(); daysDiff != 3 || err != nil {
+			t.Fatalf("expected %d days, got %d (%v)", 3, daysDiff, err)
+		}
+
+		reloaded := newHabitsAt(fake)
+		reloaded.Load(path)
+
+		if len(reloaded.Habits[0].Summary.History) != 3 {
+			t.Errorf("closed days have not been saved: %v", reloaded.Habits[0].Summary.History)
+		}
+	})
+}
+
+func TestTimeZone(t *testing.T) {
+
+	t.Run("closes a day which is 23 hours long", func(t *testing.T) {
+		warsaw, _ := time.LoadLocation("Europe/Warsaw")
+		fake := clock.NewFake(time.Date(2021, 3, 27, 23, 30, 0, 0, warsaw))
+		habits := NewHabits(fake)
+		habits.SetTimeZone("Europe/Warsaw")
+		habits.Create("Test", 1, 60, Schedule{})
+
+		fake.Advance(time.Hour)
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 1 {
+			t.Errorf("expected %d day after midnight, got %d", 1, daysDiff)
+		}
 
-		isUpdated := habits.UpdateToPresent()
+		fake.Advance(23 * time.Hour)
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 1 {
+			t.Errorf("expected %d day after the DST change, got %d", 1, daysDiff)
+		}
+
+		history := habits.Habits[0].Summary.History
+		if len(history) != 2 || history[1].Date != time.Date(2021, 3, 28, 0, 0, 0, 0, time.UTC) {
+			t.Errorf("invalid history: %v", history)
+		}
+	})
+
+	t.Run("counts the days of a file written in another zone in the configured zone", func(t *testing.T) {
+		newYork, _ := time.LoadLocation("America/New_York")
+		warsaw, _ := time.LoadLocation("Europe/Warsaw")
+		fake := clock.NewFake(time.Date(2020, 11, 21, 10, 0, 0, 0, warsaw))
+		habits := NewHabits(fake)
+		habits.SetTimeZone("Europe/Warsaw")
+		habits.Create("Test", 1, 60, Schedule{})
+		// 02:00 of the same day in Warsaw
+		habits.UpdatedAt = time.Date(2020, 11, 20, 20, 0, 0, 0, newYork)
+
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 0 {
+			t.Errorf("expected no day to be closed, got %d", daysDiff)
+		}
+	})
+
+	t.Run("changes the zone", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		habits := NewHabits(clock.NewFake(day))
+		habits.Load(path)
+		habits.Execute(command.NewCommand("tz Asia/Tokyo"))
+
+		reloaded := NewHabits(clock.NewFake(day))
+		reloaded.Load(path)
+
+		if reloaded.TimeZone != "Asia/Tokyo" {
+			t.Errorf("expected the zone to be %s, got %s", "Asia/Tokyo", reloaded.TimeZone)
+		}
+	})
+
+	t.Run("rejects an unknown zone", func(t *testing.T) {
+		habits := newTestHabits(t)
+		err := habits.Execute(command.NewCommand("tz Mars/Base"))
+
+		var usageErr command.UsageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+
+	t.Run("refuses a file with an unknown zone", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		os.WriteFile(path, []byte(fmt.Sprintf(`{"version":%d,"Habits":[],"TimeZone":"Mars/Base"}`, CurrentVersion)), 0644)
 
-		if !isUpdated {
-			t.Error("habits have not been updated")
+		if err := NewHabits(clock.NewFake(day)).Load(path); err == nil {
+			t.Error("expected an error for an unknown zone")
 		}
+	})
+}
+
+func TestDayStart(t *testing.T) {
+	evening := day.Add(22 * time.Hour)
+	nightOwl := DayStart(4 * 60)
+
+	t.Run("counts checks after midnight toward the previous day", func(t *testing.T) {
+		fake := clock.NewFake(evening)
+		habits := newHabitsAt(fake)
+		habits.DayStartsAt = nightOwl
+		habits.Create("Test", 1, 60, Schedule{})
+
+		fake.Advance(2*time.Hour + 30*time.Minute)
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 0 {
+			t.Errorf("expected no day to be closed at 00:30, got %d", daysDiff)
+		}
+		habits.Habits[0].CheckStep()
+
+		fake.Advance(4 * time.Hour)
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 1 {
+			t.Errorf("expected %d day to be closed at 04:30, got %d", 1, daysDiff)
+		}
+
+		history := habits.Habits[0].Summary.History
+		if len(history) != 1 || history[0].Date != day || history[0].CheckedSteps != 1 {
+			t.Errorf("expected the check to count toward %v, got %v", day, history)
+		}
+	})
+
+	t.Run("lets a habit override the day start", func(t *testing.T) {
+		fake := clock.NewFake(evening)
+		habits := newHabitsAt(fake)
+		habits.Create("Early", 1, 60, Schedule{})
+		habits.Create("Late", 1, 60, Schedule{})
+		habits.Habits[1].DayStartsAt = &nightOwl
+
+		fake.Advance(3 * time.Hour)
+		if daysDiff := habits.UpdateToPresent(); daysDiff != 1 {
+			t.Errorf("expected %d day to be closed, got %d", 1, daysDiff)
+		}
+
+		if len(habits.Habits[0].Summary.History) != 1 || len(habits.Habits[1].Summary.History) != 0 {
+			t.Errorf("expected only the day of Early to be closed, got %d and %d entries",
+				len(habits.Habits[0].Summary.History), len(habits.Habits[1].Summary.History))
+		}
+
+		fake.Advance(4 * time.Hour)
+		habits.UpdateToPresent()
 
-		now := time.Now()
+		if history := habits.Habits[1].Summary.History; len(history) != 1 || history[0].Date != day {
+			t.Errorf("expected the day of Late to be closed, got %v", history)
+		}
+	})
+
+	t.Run("changes the day start of the data file and of a habit", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		habits := NewHabits(clock.NewFake(evening))
+		habits.Load(path)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("ds 04:00"))
+		habits.Execute(command.NewCommand("ds 02:30 test"))
+
+		reloaded := NewHabits(clock.NewFake(evening))
+		reloaded.Load(path)
+
+		if reloaded.DayStartsAt != nightOwl || *reloaded.Habits[0].DayStartsAt != 150 {
+			t.Errorf("day starts have not been saved: %s and %v", reloaded.DayStartsAt, reloaded.Habits[0].DayStartsAt)
+		}
+
+		reloaded.Execute(command.NewCommand("ds default test"))
+
+		if reloaded.Habits[0].DayStartsAt != nil {
+			t.Errorf("day start of the habit has not been removed: %s", reloaded.Habits[0].DayStartsAt)
+		}
+	})
+
+	t.Run("rejects an invalid day start", func(t *testing.T) {
+		habits := newTestHabits(t)
+
+		for _, input := range []string{"ds 25:00", "ds default"} {
+			var usageErr command.UsageError
+			if err := habits.Execute(command.NewCommand(input)); !errors.As(err, &usageErr) {
+				t.Errorf("expected a usage error for %q, got %v", input, err)
+			}
+		}
+	})
+}
+
+func TestExecuteLog(t *testing.T) {
+	// newLoggedHabits closes two days of a habit which has not been checked
+	newLoggedHabits := func(t *testing.T) *Habits {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		fake.AdvanceDays(2)
+
+		return habits
+	}
+
+	t.Run("logs a day given as days ago", func(t *testing.T) {
+		habits := newLoggedHabits(t)
+
+		if err := habits.Execute(command.NewCommand("log test -2 1")); err != nil {
+			t.Fatal(err)
+		}
+
+		summary := habits.Habits[0].Summary
+		if summary.History[0].CheckedSteps != 1 || summary.TotalTime != (TotalTime{Hours: 1}) || summary.LongestStreak != 1 {
+			t.Errorf("day has not been logged: %+v", summary)
+		}
+	})
+
+	t.Run("logs a day given as a date", func(t *testing.T) {
+		habits := newLoggedHabits(t)
+		habits.Execute(command.NewCommand("log test 2020-11-21 1"))
+
+		if history := habits.Habits[0].Summary.History; history[1].CheckedSteps != 1 || history[1].Date != dayAfter(1) {
+			t.Errorf("day has not been logged: %v", history)
+		}
+	})
+
+	t.Run("rejects today", func(t *testing.T) {
+		habits := newLoggedHabits(t)
+
+		if err := habits.Execute(command.NewCommand("log test 2020-11-22 1")); err == nil {
+			t.Error("expected an error for today")
+		}
+	})
+
+	t.Run("rejects an invalid date", func(t *testing.T) {
+		habits := newLoggedHabits(t)
+
+		for _, input := range []string{"log test yesterday 1", "log test -0 1"} {
+			var usageErr command.UsageError
+			if err := habits.Execute(command.NewCommand(input)); !errors.As(err, &usageErr) {
+				t.Errorf("expected a usage error for %q, got %v", input, err)
+			}
+		}
+	})
+}
+
+func TestExecuteCheck(t *testing.T) {
+
+	t.Run("checks a step of a habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1"))
+
+		if habits.Habits[0].CheckedSteps != 1 {
+			t.Errorf("expected CheckedSteps to be %d, got %d", 1, habits.Habits[0].CheckedSteps)
+		}
+	})
+
+	t.Run("checks n steps of a habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1 3"))
+
+		if habits.Habits[0].CheckedSteps != 3 {
+			t.Errorf("expected CheckedSteps to be %d, got %d", 3, habits.Habits[0].CheckedSteps)
+		}
+	})
+
+	t.Run("checks steps of several habits", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("First", 2, 60, Schedule{})
+		habits.Create("Second", 2, 60, Schedule{})
+		habits.Create("Third", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1,3 2"))
+
+		for idx, want := range []int8{2, 0, 2} {
+			if habits.Habits[idx].CheckedSteps != want {
+				t.Errorf("expected CheckedSteps of habit %d to be %d, got %d", idx, want, habits.Habits[idx].CheckedSteps)
+			}
+		}
+	})
+
+	t.Run("counts the time of the most steps of the longest habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 1, MaxHabitTotalTime, Schedule{})
+		habits.Execute(command.NewCommand("c 1 127"))
+		habits.Habits[0].UpdateToPresent(day, 1)
+
+		if total := habits.Habits[0].Summary.TotalTime; total != (TotalTime{Days: 84, Hours: 16}) {
+			t.Errorf("expected 127 steps of %d minutes, got %+v", MaxHabitTotalTime, total)
+		}
+	})
+
+	t.Run("does not check any habit when one of the references is invalid", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1,2"))
+
+		if habits.Habits[0].CheckedSteps != 0 {
+			t.Errorf("expected CheckedSteps to be %d, got %d", 0, habits.Habits[0].CheckedSteps)
+		}
+	})
+
+	t.Run("saves the checked steps", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1"))
+
+		loaded := NewHabits(clock.NewFake(day))
+		if err := loaded.Load(habits.Path()); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(loaded.Habits) != 1 || loaded.Habits[0].CheckedSteps != 1 {
+			t.Error("checked steps have not been saved")
+		}
+	})
+}
+
+func TestExecuteUncheck(t *testing.T) {
+
+	t.Run("unchecks n steps of a habit", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("Test", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1 3"))
+		habits.Execute(command.NewCommand("u 1 2"))
+
+		if habits.Habits[0].CheckedSteps != 1 {
+			t.Errorf("expected CheckedSteps to be %d, got %d", 1, habits.Habits[0].CheckedSteps)
+		}
+	})
 
-		isDateUpdated := habits.UpdatedAt.Year() == now.Year() &&
-			habits.UpdatedAt.Month() == now.Month() &&
-			habits.UpdatedAt.Day() == now.Day()
+	t.Run("unchecks a step of several habits", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Create("First", 2, 60, Schedule{})
+		habits.Create("Second", 2, 60, Schedule{})
+		habits.Execute(command.NewCommand("c 1,2 2"))
+		habits.Execute(command.NewCommand("u 1,2"))
 
-		if !isDateUpdated {
-			t.Error("date have not been updated")
+		for idx := range habits.Habits {
+			if habits.Habits[idx].CheckedSteps != 1 {
+				t.Errorf("expected CheckedSteps of habit %d to be %d, got %d", idx, 1, habits.Habits[idx].CheckedSteps)
+			}
 		}
 	})
 }
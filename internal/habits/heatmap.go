@@ -0,0 +1,136 @@
+package habits
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+const DefaultHeatmapWeeks = 53 // a year, the current week included
+const MaxHeatmapWeeks = 520
+
+// heatmapDay sums the entries of the habits on a day. The checked steps of a habit are counted up to its goal,
+// so a habit done over its goal does not make up for a missed one.
+type heatmapDay struct {
+	stepsCount   int
+	checkedSteps int
+	over         int // habits checked over their goal
+	tracked      int // number of entries, the frozen ones included
+	frozen       int
+	offDays      int // off days without checked steps
+}
+
+func (d *heatmapDay) add(entry Entry) {
+	d.tracked += 1
+
+	switch {
+	case entry.IsFrozen:
+		d.frozen += 1
+	case entry.IsOffDay && entry.CheckedSteps == 0:
+		d.offDays += 1
+	default:
+		d.stepsCount += int(entry.StepsCount)
+		d.checkedSteps += min(int(entry.CheckedSteps), int(entry.StepsCount))
+
+		if entry.CheckedSteps > entry.StepsCount {
+			d.over += 1
+		}
+	}
+}
+
+// cell draws the day, the intensity is the ratio of the checked steps to the goal.
+// A day on which every habit is frozen or off is drawn as such, a day on which every habit reached
+// its goal and some went over it is yellow.
+func (d heatmapDay) cell() string {
+	switch {
+	case d.tracked == 0:
+		return " "
+	case d.frozen == d.tracked:
+		return utils.ColorString(utils.FgColors.Blue, "▄")
+	case d.frozen+d.offDays == d.tracked:
+		return "·"
+	case d.checkedSteps == 0:
+		return "░"
+	case d.checkedSteps == d.stepsCount && d.over > 0:
+		return utils.ColorString(utils.FgColors.Yellow, "█")
+	case d.checkedSteps == d.stepsCount:
+		return utils.ColorString(utils.FgColors.Green, "█")
+	case d.checkedSteps*2 >= d.stepsCount:
+		return utils.ColorString(utils.FgColors.Green, "▓")
+	default:
+		return utils.ColorString(utils.FgColors.Green, "▒")
+	}
+}
+
+// renderHeatmap draws a column per week, from Monday to Sunday, ending with the current week.
+// The days of all habits are added up, so a single habit is drawn by passing only that habit.
+func (h *Habits) renderHeatmap(title string, habits []Habit, weeks int) string {
+	today := h.today(nil)
+	start := utils.GetBeginningOfWeekDate(today).AddDate(0, 0, -7*(weeks-1))
+	days := map[time.Time]*heatmapDay{}
+
+	add := func(entry Entry) {
+		if _, ok := days[entry.Date]; !ok {
+			days[entry.Date] = &heatmapDay{}
+		}
+
+		days[entry.Date].add(entry)
+	}
+
+	for _, habit := range habits {
+		for _, entry := range h.getHistory(habit, start, today) {
+			add(entry)
+		}
+
+		add(habit.getCurrentEntry(h.today(&habit)))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s, %s - %s\n", title, start.Format(time.DateOnly), today.Format(time.DateOnly))
+
+	// The name of a month is placed over the week of its first day, when there is room for it.
+	// The first week is labeled too, unless the next month begins right after it.
+	hasFirst := func(week int) bool {
+		monday := start.AddDate(0, 0, 7*week)
+		return monday.Day() == 1 || monday.AddDate(0, 0, 6).Month() != monday.Month()
+	}
+
+	months := []byte(strings.Repeat(" ", weeks*2+1))
+	for week, end := 0, 0; week < weeks; week++ {
+		if week*2 < end || !hasFirst(week) && (week > 0 || hasFirst(1)) {
+			continue
+		}
+
+		copy(months[week*2:], start.AddDate(0, 0, 7*week+6).Format("Jan"))
+		end = week*2 + 4
+	}
+	sb.WriteString("    " + strings.TrimRight(string(months), " ") + "\n")
+
+	for weekday := range 7 {
+		label := ""
+		if weekday%2 == 0 && weekday < 6 {
+			label = start.AddDate(0, 0, weekday).Format("Mon")
+		}
+
+		cells := make([]string, weeks)
+		for week := range weeks {
+			date := start.AddDate(0, 0, 7*week+weekday)
+
+			if day, ok := days[date]; ok && !date.After(today) {
+				cells[week] = day.cell()
+			} else {
+				cells[week] = " "
+			}
+		}
+
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%-4s%s", label, strings.Join(cells, " ")), " ") + "\n")
+	}
+
+	fmt.Fprintf(&sb, "\nLess ░ %s %s %s More  %s over  %s frozen  · off day",
+		utils.ColorString(utils.FgColors.Green, "▒"), utils.ColorString(utils.FgColors.Green, "▓"), utils.ColorString(utils.FgColors.Green, "█"),
+		utils.ColorString(utils.FgColors.Yellow, "█"), utils.ColorString(utils.FgColors.Blue, "▄"))
+
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package habits
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/command"
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+var ansiCodes = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// newHeatmapHabits tracks Read, 2 steps daily, and Gym on Mondays and Wednesdays from Monday 2020-11-16
+// to Friday 2020-11-20, on which 2 steps of Read are checked.
+func newHeatmapHabits(t *testing.T) *Habits {
+	gym := Schedule{Kind: ScheduleWeekdays, Weekdays: []time.Weekday{time.Monday, time.Wednesday}}
+
+	return newHabitsWith(t,
+		habitSpec{name: "Read", stepsCount: 2, stepMinutes: 30, history: "031f", checkedSteps: 2},
+		habitSpec{name: "Gym", stepsCount: 1, stepMinutes: 60, schedule: gym, history: "1o0f"},
+	)
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	t.Run("draws a habit", func(t *testing.T) {
+		habits := newHeatmapHabits(t)
+		output := habits.renderHeatmap("Read", habits.Habits[:1], 2)
+
+		expected := "Read, 2020-11-09 - 2020-11-20\n" +
+			"    Nov\n" +
+			"Mon   ░\n" +
+			"      █\n" +
+			"Wed   ▓\n" +
+			"      ▄\n" +
+			"Fri   █\n" +
+			"\n" +
+			"\n" +
+			"\n" +
+			"Less ░ ▒ ▓ █ More  █ over  ▄ frozen  · off day"
+
+		if plain := ansiCodes.ReplaceAllString(output, ""); plain != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, plain)
+		}
+
+		if !strings.Contains(output, utils.ColorString(utils.FgColors.Yellow, "█")+"\n") {
+			t.Errorf("expected the over-achieved day to be yellow, got\n%s", output)
+		}
+	})
+
+	t.Run("adds up all habits", func(t *testing.T) {
+		habits := newHeatmapHabits(t)
+		plain := ansiCodes.ReplaceAllString(habits.renderHeatmap("All habits", habits.Habits, 2), "")
+		rows := strings.Split(plain, "\n")
+
+		// 1 of 3 steps on Monday and Wednesday, Gym is off on Tuesday and Friday
+		expected := []string{"Mon   ▒", "      █", "Wed   ▒", "      ▄", "Fri   █"}
+
+		for idx, row := range expected {
+			if rows[idx+2] != row {
+				t.Errorf("expected row %d to be %q, got %q", idx, row, rows[idx+2])
+			}
+		}
+	})
+
+	t.Run("labels the months over their first weeks", func(t *testing.T) {
+		habits := newHeatmapHabits(t)
+		plain := ansiCodes.ReplaceAllString(habits.renderHeatmap("Read", habits.Habits[:1], 10), "")
+
+		// 2020-09-14 is the first Monday, October begins in the third week and November in the seventh
+		if months := strings.Split(plain, "\n")[1]; months != "    Sep Oct     Nov" {
+			t.Errorf("expected the labels of September, October and November, got %q", months)
+		}
+	})
+
+	t.Run("rejects an invalid number of weeks", func(t *testing.T) {
+		habits := newHeatmapHabits(t)
+		err := habits.Execute(command.NewCommand("heatmap read --weeks 0"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
+
+func TestHeatmapDayCell(t *testing.T) {
+	var tests = []struct {
+		name    string
+		entries []Entry
+		want    string
+	}{
+		{"is empty without entries", nil, " "},
+		{"marks a frozen day", []Entry{{IsFrozen: true}}, utils.ColorString(utils.FgColors.Blue, "▄")},
+		{"marks an off day", []Entry{{IsFrozen: true}, {StepsCount: 1, IsOffDay: true}}, "·"},
+		{"marks a missed day", []Entry{{StepsCount: 2}}, "░"},
+		{"is light below half of the goal", []Entry{{StepsCount: 3, CheckedSteps: 1}}, utils.ColorString(utils.FgColors.Green, "▒")},
+		{"is dark from half of the goal", []Entry{{StepsCount: 2, CheckedSteps: 1}}, utils.ColorString(utils.FgColors.Green, "▓")},
+		{"is full at the goal", []Entry{{StepsCount: 2, CheckedSteps: 2}, {IsFrozen: true}}, utils.ColorString(utils.FgColors.Green, "█")},
+		{"is yellow over the goal", []Entry{{StepsCount: 2, CheckedSteps: 3}}, utils.ColorString(utils.FgColors.Yellow, "█")},
+		{"is yellow when every habit reached its goal and one went over", []Entry{{StepsCount: 1, CheckedSteps: 2}, {StepsCount: 2, CheckedSteps: 2}}, utils.ColorString(utils.FgColors.Yellow, "█")},
+		{"does not make up for a missed habit with an over-achieved one", []Entry{{StepsCount: 1, CheckedSteps: 5}, {StepsCount: 2}}, utils.ColorString(utils.FgColors.Green, "▒")},
+		{"counts the steps of each habit up to its goal", []Entry{{StepsCount: 1, CheckedSteps: 5}, {StepsCount: 1}}, utils.ColorString(utils.FgColors.Green, "▓")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := heatmapDay{}
+			for _, entry := range tt.entries {
+				day.add(entry)
+			}
+
+			if got := day.cell(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package habits
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+const icsProductID = "-//seektor//habits-tracker-go//EN"
+const icsDateFormat = "20060102"
+const icsMaxLineLength = 75 // octets, without the line break
+
+var icsWeekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icsWriter writes the content lines of RFC 5545, the first error is kept and returned by WriteICS.
+type icsWriter struct {
+	w     io.Writer
+	stamp string
+	err   error
+}
+
+// line writes the content line terminated by CRLF, folded into lines of at most 75 octets
+// without splitting a character. The continuation lines begin with a space.
+func (w *icsWriter) line(name string, value string) {
+	content := name + ":" + value
+	limit := icsMaxLineLength
+
+	for w.err == nil {
+		if len(content) <= limit {
+			_, w.err = io.WriteString(w.w, content+"\r\n")
+			return
+		}
+
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+
+		_, w.err = io.WriteString(w.w, content[:cut]+"\r\n ")
+		content, limit = content[cut:], icsMaxLineLength-1
+	}
+}
+
+func (w *icsWriter) text(name string, value string) {
+	w.line(name, icsEscaper.Replace(value))
+}
+
+// allDayEvent writes a VEVENT of the days from start to end, both included, repeated by the rule when it is not empty,
+// without the occurrences which begin on the excluded dates.
+// The habits do not take a fixed time of a day, so the events are transparent to free/busy searches.
+func (w *icsWriter) allDayEvent(uid string, start time.Time, end time.Time, rule string, summary string, description string, excluded ...time.Time) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", uid)
+	w.line("DTSTAMP", w.stamp)
+	w.line("DTSTART;VALUE=DATE", start.Format(icsDateFormat))
+	w.line("DTEND;VALUE=DATE", end.AddDate(0, 0, 1).Format(icsDateFormat))
+
+	if rule != "" {
+		w.line("RRULE", rule)
+	}
+
+	if len(excluded) > 0 {
+		dates := make([]string, len(excluded))
+		for idx, date := range excluded {
+			dates[idx] = date.Format(icsDateFormat)
+		}
+
+		w.line("EXDATE;VALUE=DATE", strings.Join(dates, ","))
+	}
+
+	w.text("SUMMARY", summary)
+	w.text("DESCRIPTION", description)
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+func icsUID(habit Habit, kind string, date time.Time) string {
+	uid := fmt.Sprintf("habit-%d-%s", habit.ID, kind)
+
+	if !date.IsZero() {
+		uid += "-" + date.Format(icsDateFormat)
+	}
+
+	return uid + "@habits-tracker-go"
+}
+
+// getRecurrenceRule returns the rule of the schedule and the first day of the recurrence from the date,
+// the first occurrence has to match the rule. A week of a ScheduleTimesPerWeek habit is a single occurrence.
+func getRecurrenceRule(schedule Schedule, from time.Time) (string, time.Time, time.Time) {
+	switch schedule.Kind {
+	case ScheduleWeekdays:
+		for !schedule.IsDue(from) {
+			from = from.AddDate(0, 0, 1)
+		}
+
+		days := make([]string, len(schedule.Weekdays))
+		for idx, weekday := range schedule.Weekdays {
+			days[idx] = icsWeekdays[weekday]
+		}
+
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ","), from, from
+	case ScheduleInterval:
+		if from.Before(schedule.Start) {
+			from = schedule.Start
+		}
+
+		for !schedule.IsDue(from) {
+			from = from.AddDate(0, 0, 1)
+		}
+
+		return fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", schedule.Every), from, from
+	case ScheduleTimesPerWeek:
+		from = utils.GetBeginningOfWeekDate(from)
+
+		return "FREQ=WEEKLY", from, from.AddDate(0, 0, 6)
+	default:
+		return "FREQ=DAILY", from, from
+	}
+}
+
+func getScheduleDescription(habit Habit) string {
+	goal := fmt.Sprintf("%d x %d min", habit.StepsCount, habit.StepMinutes)
+
+	switch habit.Schedule.Kind {
+	case ScheduleWeekdays:
+		return goal + " on " + habit.Schedule.String()
+	case ScheduleInterval:
+		return fmt.Sprintf("%s every %d days", goal, habit.Schedule.Every)
+	case ScheduleTimesPerWeek:
+		return fmt.Sprintf("%s, %d times per week", goal, habit.Schedule.Times)
+	default:
+		return goal + " daily"
+	}
+}
+
+// getFrozenDates returns the frozen days of the habit in order, the current day included.
+func (h *Habits) getFrozenDates(habit Habit) []time.Time {
+	dates := []time.Time{}
+
+	for _, entry := range habit.Summary.History {
+		if entry.IsFrozen {
+			dates = append(dates, entry.Date)
+		}
+	}
+
+	if habit.IsFrozen {
+		dates = append(dates, h.today(&habit))
+	}
+
+	return dates
+}
+
+// getExcludedDates returns the occurrences of the schedule from the start which fall on the frozen days.
+// A week of a ScheduleTimesPerWeek habit is excluded only when all of its days are frozen.
+func getExcludedDates(schedule Schedule, start time.Time, frozen []time.Time) []time.Time {
+	excluded := []time.Time{}
+	isFrozen := map[time.Time]bool{}
+
+	for _, date := range frozen {
+		isFrozen[date] = true
+	}
+
+	for _, date := range frozen {
+		switch {
+		case date.Before(start):
+			continue
+		case schedule.Kind == ScheduleTimesPerWeek:
+			if date.Weekday() != time.Monday {
+				continue
+			}
+
+			days := 1
+			for days < 7 && isFrozen[date.AddDate(0, 0, days)] {
+				days++
+			}
+
+			if days == 7 {
+				excluded = append(excluded, date)
+			}
+		case schedule.IsDue(date):
+			excluded = append(excluded, date)
+		}
+	}
+
+	return excluded
+}
+
+// writeFrozenPeriods writes an event per run of consecutive frozen days.
+func writeFrozenPeriods(w *icsWriter, habit Habit, dates []time.Time) {
+	for start := 0; start < len(dates); {
+		end := start
+		for end+1 < len(dates) && utils.GetDaysDiff(dates[end], dates[end+1]) == 1 {
+			end++
+		}
+
+		days := utils.GetDaysDiff(dates[start], dates[end]) + 1
+		w.allDayEvent(icsUID(habit, "frozen", dates[start]), dates[start], dates[end], "",
+			habit.Name+" (frozen)", fmt.Sprintf("Days frozen: %d", days))
+		start = end + 1
+	}
+}
+
+// WriteICS writes an iCalendar file with a recurring event of the schedule of every habit which is not frozen,
+// without the frozen days, an event per closed day with checked steps and an event per frozen period. The UIDs are stable,
+// so importing the file again updates the events instead of duplicating them.
+func (h *Habits) WriteICS(out io.Writer, habits ...Habit) error {
+	w := &icsWriter{w: out, stamp: h.clock.Now().UTC().Format("20060102T150405Z")}
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", icsProductID)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", "Habits")
+
+	for _, habit := range habits {
+		from := utils.GetDayDate(habit.CreatedAt, h.location, int(h.dayStart(&habit)))
+		if len(habit.Summary.History) > 0 && habit.Summary.History[0].Date.Before(from) {
+			from = habit.Summary.History[0].Date
+		}
+
+		frozen := h.getFrozenDates(habit)
+
+		if !habit.IsFrozen {
+			rule, start, end := getRecurrenceRule(habit.Schedule, from)
+			w.allDayEvent(icsUID(habit, "schedule", time.Time{}), start, end, rule, habit.Name, getScheduleDescription(habit),
+				getExcludedDates(habit.Schedule, start, frozen)...)
+		}
+
+		writeFrozenPeriods(w, habit, frozen)
+
+		for _, entry := range habit.Summary.History {
+			if entry.IsFrozen || entry.CheckedSteps == 0 {
+				continue
+			}
+
+			status := "partially done"
+			if entry.IsFulfilled() {
+				status = "done"
+			}
+
+			w.allDayEvent(icsUID(habit, "day", entry.Date), entry.Date, entry.Date, "",
+				fmt.Sprintf("%s - %d min", habit.Name, entry.Minutes()),
+				fmt.Sprintf("%d of %d steps checked, %d minutes, %s", entry.CheckedSteps, entry.StepsCount, entry.Minutes(), status))
+		}
+	}
+
+	w.line("END", "VCALENDAR")
+
+	return w.err
+}
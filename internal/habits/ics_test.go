@@ -0,0 +1,204 @@
+package habits
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+// newICSHabits tracks habits of every schedule from Friday 2020-11-20 to Wednesday 2020-11-25,
+// Read has a partial day, full days and a frozen period and Piano is frozen now.
+func newICSHabits(t *testing.T) *Habits {
+	fake := clock.NewFake(day.Add(12 * time.Hour))
+	habits := newHabitsAt(fake)
+
+	if err := habits.Load(filepath.Join(t.TempDir(), "habits.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"a", "Read; write", "2", "30"},
+		{"a", "Gym", "1", "60", "mon,wed,fri"},
+		{"a", "Water", "1", "5", "3d"},
+		{"a", "Run", "1", "45", "3/w"},
+		{"a", "Piano", "1", "20"},
+		{"c", "1,2,3,4"},
+	} {
+		if err := habits.Execute(command.NewCommandFromArgs(args)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake.AdvanceDays(1)
+	habits.Execute(command.NewCommand("c 1,4"))
+	habits.Execute(command.NewCommand("c 1"))
+	fake.AdvanceDays(1)
+	habits.Execute(command.NewCommand("f 1"))
+	fake.AdvanceDays(2)
+	habits.Execute(command.NewCommand("uf 1"))
+	habits.Execute(command.NewCommand("c 1 2"))
+	fake.AdvanceDays(1)
+	habits.Execute(command.NewCommand("f piano"))
+
+	return habits
+}
+
+func TestWriteICS(t *testing.T) {
+	var tests = []struct {
+		name   string
+		habits func(t *testing.T) *Habits
+	}{
+		{"habits", newICSHabits},
+		{"empty", func(t *testing.T) *Habits { return newHabitsAt(clock.NewFake(day)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habits := tt.habits(t)
+			var buf bytes.Buffer
+
+			if err := habits.WriteICS(&buf, habits.Habits...); err != nil {
+				t.Fatal(err)
+			}
+
+			checkICS(t, buf.String())
+
+			golden := filepath.Join("testdata", "ics", tt.name+".ics")
+			if *update {
+				os.WriteFile(golden, buf.Bytes(), 0644)
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != string(want) {
+				t.Errorf("%s differs from the output, run the tests with -update to see the difference in git:\n%s", golden, buf.String())
+			}
+		})
+	}
+
+	t.Run("is written by the export command", func(t *testing.T) {
+		habits := newICSHabits(t)
+		path := filepath.Join(t.TempDir(), "habits.ics")
+
+		if err := habits.Execute(command.NewCommand("export ics " + path)); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(path)
+
+		if err != nil || !strings.HasPrefix(string(data), "BEGIN:VCALENDAR\r\n") {
+			t.Errorf("expected a calendar, got %q (%v)", data, err)
+		}
+	})
+}
+
+// checkICS checks the rules of RFC 5545 which the golden files could hide: CRLF line breaks,
+// lines of at most 75 octets, nested components and the required properties of the events.
+func checkICS(t *testing.T, content string) {
+	if !strings.HasSuffix(content, "\r\n") || strings.Contains(strings.ReplaceAll(content, "\r\n", ""), "\n") {
+		t.Errorf("expected the lines to end with CRLF")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\r\n"), "\r\n")
+	components := []string{}
+	properties := map[string]bool{}
+
+	for _, line := range lines {
+		if len(line) > icsMaxLineLength {
+			t.Errorf("expected at most %d octets, got %d in %q", icsMaxLineLength, len(line), line)
+		}
+
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, ":")
+		name, _, _ = strings.Cut(name, ";")
+
+		switch name {
+		case "BEGIN":
+			components = append(components, value)
+			properties = map[string]bool{}
+		case "END":
+			if len(components) == 0 || components[len(components)-1] != value {
+				t.Fatalf("unexpected END:%s in %v", value, components)
+			}
+
+			for _, required := range []string{"UID", "DTSTAMP", "DTSTART"} {
+				if value == "VEVENT" && !properties[required] {
+					t.Errorf("expected the event to have %s", required)
+				}
+			}
+
+			components = components[:len(components)-1]
+		default:
+			properties[name] = true
+		}
+	}
+
+	if len(components) != 0 {
+		t.Errorf("expected all components to be closed, got %v", components)
+	}
+}
+
+func TestICSWriterLine(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"keeps a short line", "Read", "SUMMARY:Read\r\n"},
+		{"escapes the text", `a,b;c\d` + "\ne", `SUMMARY:a\,b\;c\\d\ne` + "\r\n"},
+		{"folds a long line", strings.Repeat("a", 80), "SUMMARY:" + strings.Repeat("a", 67) + "\r\n " + strings.Repeat("a", 13) + "\r\n"},
+		{"does not split a character", strings.Repeat("a", 66) + "żż", "SUMMARY:" + strings.Repeat("a", 66) + "\r\n żż\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &icsWriter{w: &buf}
+			w.text("SUMMARY", tt.value)
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestGetExcludedDates(t *testing.T) {
+	// Frozen from Sunday 2020-11-15 to Monday 2020-11-23
+	frozen := []time.Time{}
+	for days := -5; days <= 3; days++ {
+		frozen = append(frozen, dayAfter(days))
+	}
+
+	var tests = []struct {
+		name     string
+		schedule Schedule
+		start    time.Time
+		want     []time.Time
+	}{
+		{"excludes the frozen days from the start", Schedule{}, dayAfter(1), []time.Time{dayAfter(1), dayAfter(2), dayAfter(3)}},
+		{"excludes only the due days", Schedule{Kind: ScheduleWeekdays, Weekdays: []time.Weekday{time.Monday, time.Friday}}, dayAfter(-4), []time.Time{dayAfter(-4), day, dayAfter(3)}},
+		{"excludes the weeks frozen as a whole", Schedule{Kind: ScheduleTimesPerWeek, Times: 3}, dayAfter(-11), []time.Time{dayAfter(-4)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getExcludedDates(tt.schedule, tt.start, frozen); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
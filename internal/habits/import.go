@@ -0,0 +1,203 @@
+package habits
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/seektor/habits-tracker-go/internal/importer"
+)
+
+// importResult counts the days of an import, the conflicts describe the days which differ from the tracked ones.
+type importResult struct {
+	Added     int
+	Replaced  int
+	Unchanged int
+	Created   []string
+	Conflicts []string
+	Warnings  []string
+}
+
+// importedNameSuffix is added to a numeric name, which would be taken for the ID of a habit.
+const importedNameSuffix = " habit"
+
+// getImportedName makes the name of another tracker a valid habit name, without commas, not numeric
+// and shortened to MaxHabitNameLength.
+func getImportedName(name string) string {
+	name = shortenName(strings.Join(strings.Fields(strings.ReplaceAll(name, ",", " ")), " "), int(MaxHabitNameLength))
+
+	if _, err := strconv.Atoi(name); err == nil {
+		name = shortenName(name, int(MaxHabitNameLength)-len(importedNameSuffix)) + importedNameSuffix
+	}
+
+	return name
+}
+
+func shortenName(name string, length int) string {
+	for len(name) > length {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = strings.TrimSpace(name[:len(name)-size])
+	}
+
+	return name
+}
+
+// getStepMinutes splits the minutes of a day into its checked steps, clamped to the step time which
+// a habit of the steps count can have. False is returned when the minutes had to be clamped.
+func getStepMinutes(minutes int, checkedSteps int8, stepsCount int8) (int16, bool) {
+	limit := int(MaxHabitTotalTime) / max(1, int(stepsCount))
+	stepMinutes := minutes / max(1, int(checkedSteps))
+
+	return int16(min(max(stepMinutes, 1), limit)), stepMinutes >= 1 && stepMinutes <= limit
+}
+
+// toEntry converts the day to an entry of the habit, the step time is derived from the minutes
+// and falls back to the one of the habit for a day without checked steps. The schedule of the habit
+// tells whether the day was due, unless the imported habit tells it.
+// False is returned when the minutes do not fit the checked steps and have been clamped.
+func toEntry(day importer.Day, imported importer.Habit, habit *Habit) (Entry, bool) {
+	if day.IsFrozen {
+		return Entry{Date: day.Date, IsFrozen: true}, true
+	}
+
+	stepsCount, stepMinutes, ok := day.StepsCount, habit.StepMinutes, true
+	if stepsCount == 0 {
+		stepsCount = habit.StepsCount
+	}
+
+	if day.CheckedSteps > 0 {
+		stepMinutes, ok = getStepMinutes(day.Minutes, day.CheckedSteps, stepsCount)
+	}
+
+	isOffDay := !habit.Schedule.IsDue(day.Date)
+	if imported.HasOffDays {
+		isOffDay = day.IsOffDay
+	}
+
+	return Entry{
+		Date:         day.Date,
+		StepsCount:   stepsCount,
+		StepMinutes:  stepMinutes,
+		CheckedSteps: day.CheckedSteps,
+		IsOffDay:     isOffDay,
+		WeeklyTimes:  habit.Schedule.Times,
+	}, ok
+}
+
+// isSameDay compares what the trackers export, the other fields are derived from the habit.
+func isSameDay(a, b Entry) bool {
+	return a.StepsCount == b.StepsCount && a.CheckedSteps == b.CheckedSteps && a.Minutes() == b.Minutes() && a.IsFrozen == b.IsFrozen
+}
+
+// getImportedHabit finds the habit by its name, ignoring the case. A missing habit is created
+// with the settings of the imported one, the unknown ones are filled with the smallest values.
+// Its schedule starts on the start of the imported habit or on the first imported day, so an interval
+// is due on the days of the other tracker.
+func (h *Habits) getImportedHabit(imported importer.Habit, result *importResult) (*Habit, error) {
+	name := getImportedName(imported.Name)
+	idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return strings.EqualFold(item.Name, name) })
+
+	if idx >= 0 {
+		return &h.Habits[idx], nil
+	}
+
+	scheduleValue := imported.Schedule
+	if scheduleValue == "" {
+		scheduleValue = "daily"
+	}
+
+	start := imported.Start
+	if start.IsZero() {
+		start = h.today(nil)
+
+		for _, day := range imported.Days {
+			if day.Date.Before(start) {
+				start = day.Date
+			}
+		}
+	}
+
+	schedule, err := ParseSchedule(scheduleValue, start)
+
+	if err != nil {
+		return nil, fmt.Errorf("habit %q cannot be created: %w", name, err)
+	}
+
+	stepsCount := max(1, imported.StepsCount)
+	stepMinutes := min(max(1, imported.StepMinutes), MaxHabitTotalTime/int16(stepsCount))
+
+	if err := h.emit(Event{Type: EventCreated, Name: name, StepsCount: stepsCount, StepMinutes: stepMinutes, Schedule: &schedule}); err != nil {
+		return nil, fmt.Errorf("habit %q cannot be created: %w", name, err)
+	}
+
+	result.Created = append(result.Created, name)
+
+	return &h.Habits[len(h.Habits)-1], nil
+}
+
+// importHabits merges the days into the history of the habits. A day which differs from the tracked one
+// is a conflict and is replaced only when overwrite is set. Days which have not been closed yet are not imported.
+func (h *Habits) importHabits(imported []importer.Habit, overwrite bool) (importResult, error) {
+	result := importResult{}
+
+	for _, item := range imported {
+		habit, err := h.getImportedHabit(item, &result)
+
+		if err != nil {
+			return result, err
+		}
+
+		for _, warning := range item.Warnings {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", habit.Name, warning))
+		}
+
+		for _, day := range item.Days {
+			source := ""
+			if day.Source != "" {
+				source = day.Source + ": "
+			}
+
+			date := day.Date.Format(time.DateOnly)
+
+			if !day.Date.Before(h.today(habit)) {
+				result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s%s of %s has not been closed yet", source, date, habit.Name))
+				continue
+			}
+
+			entry, ok := toEntry(day, item, habit)
+
+			if !ok {
+				result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s%s of %s has %d min which do not fit %d checked steps, %d min per step is imported",
+					source, date, habit.Name, day.Minutes, day.CheckedSteps, entry.StepMinutes))
+			}
+			idx, found := slices.BinarySearchFunc(habit.Summary.History, entry.Date, func(e Entry, date time.Time) int {
+				return e.Date.Compare(date)
+			})
+
+			switch {
+			case !found:
+				result.Added += 1
+			case isSameDay(habit.Summary.History[idx], entry):
+				result.Unchanged += 1
+				continue
+			case !overwrite:
+				existing := habit.Summary.History[idx]
+				result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s%s of %s has %d/%d steps checked (%d min, frozen %v), the import has %d/%d (%d min, frozen %v)",
+					source, date, habit.Name, existing.CheckedSteps, existing.StepsCount, existing.Minutes(), existing.IsFrozen,
+					entry.CheckedSteps, entry.StepsCount, entry.Minutes(), entry.IsFrozen))
+				continue
+			default:
+				result.Replaced += 1
+			}
+
+			if err := h.emit(Event{Type: EventDayImported, Habit: habit.ID, Entry: &entry}); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
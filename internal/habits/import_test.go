@@ -0,0 +1,219 @@
+package habits
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+	"github.com/seektor/habits-tracker-go/internal/importer"
+)
+
+// writeLoopExport writes an extracted Loop export, Read is checked on 2020-11-20 and 2020-11-22 and skipped in between.
+func writeLoopExport(t *testing.T) string {
+	return writeLoopFiles(t, map[string]string{
+		"Habits.csv": "Position,Name,Type,Question,Description,FrequencyNumerator,FrequencyDenominator,Color,Unit,Target Type,Target Value,Archived?\n" +
+			"001,Read,0,,,1,1,#000000,,0,0,false\n" +
+			"002,Run,0,,,3,7,#000000,,0,0,false\n",
+		"Checkmarks.csv": "Date,Read,Run,\n" +
+			"2020-11-23,YES_MANUAL,NO,\n" +
+			"2020-11-22,YES_MANUAL,NO,\n" +
+			"2020-11-21,SKIP,YES_MANUAL,\n" +
+			"2020-11-20,YES_MANUAL,UNKNOWN,\n",
+	})
+}
+
+func writeLoopFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestImportLoop(t *testing.T) {
+	newLoopHabits := func(t *testing.T) *Habits {
+		habits := newHabitsAt(clock.NewFake(dayAfter(3)))
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+
+		return habits
+	}
+
+	t.Run("creates the habits with their summary", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		dir := writeLoopExport(t)
+
+		if err := habits.Execute(command.NewCommand("import loop " + dir + " --step-minutes 20")); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(habits.Habits) != 2 {
+			t.Fatalf("expected 2 habits, got %+v", habits.Habits)
+		}
+
+		read, run := habits.Habits[0], habits.Habits[1]
+
+		if read.StepMinutes != 20 || len(read.Summary.History) != 3 || !read.Summary.History[1].IsFrozen {
+			t.Errorf("expected Read to be created with its history, got %+v", read)
+		}
+
+		if read.Summary.CurrentStreak != 2 || read.Summary.TotalTime != (TotalTime{Minutes: 40}) {
+			t.Errorf("expected the streak of 2 days and 40 minutes, got %+v", read.Summary)
+		}
+
+		if run.Schedule.Kind != ScheduleTimesPerWeek || run.Schedule.Times != 3 || len(run.Summary.History) != 2 {
+			t.Errorf("expected Run to be scheduled 3 times per week from its first known day, got %+v", run)
+		}
+
+		reloaded := newLoopHabits(t)
+		reloaded.Load(habits.Path())
+
+		if len(reloaded.Habits) != 2 || reloaded.Habits[0].Summary.TotalTime != read.Summary.TotalTime {
+			t.Errorf("expected the import to be saved, got %+v", reloaded.Habits)
+		}
+	})
+
+	t.Run("counts an interval from the last check and imports the automatic days as off days", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		dir := writeLoopFiles(t, map[string]string{
+			"Habits.csv": "Position,Name,Type,Question,Description,FrequencyNumerator,FrequencyDenominator,Color,Unit,Target Type,Target Value,Archived?\n" +
+				"001,Swim,0,,,1,2,#000000,,0,0,false\n",
+			"Checkmarks.csv": "Date,Swim,\n" +
+				"2020-11-22,YES_MANUAL,\n" +
+				"2020-11-21,NO,\n" +
+				"2020-11-20,YES_AUTO,\n" +
+				"2020-11-19,YES_MANUAL,\n",
+		})
+
+		if err := habits.Execute(command.NewCommand("import loop " + dir)); err != nil {
+			t.Fatal(err)
+		}
+
+		swim := habits.Habits[0]
+		history := swim.Summary.History
+
+		if len(history) != 4 || history[0].IsOffDay || !history[1].IsOffDay || history[2].IsOffDay || history[3].IsOffDay {
+			t.Errorf("expected only the automatic day to be an off day, got %+v", history)
+		}
+
+		if swim.Summary.CurrentStreak != 1 || swim.Summary.LongestStreak != 1 {
+			t.Errorf("expected the unchecked day to break the streak, got %+v", swim.Summary)
+		}
+
+		if swim.Schedule.IsDue(dayAfter(3)) || !swim.Schedule.IsDue(dayAfter(4)) {
+			t.Errorf("expected every other day to be due from 2020-11-22, got %+v", swim.Schedule)
+		}
+	})
+
+	t.Run("reports a frequency which is not supported", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		dir := writeLoopFiles(t, map[string]string{
+			"Habits.csv": "Position,Name,Type,Question,Description,FrequencyNumerator,FrequencyDenominator,Color,Unit,Target Type,Target Value,Archived?\n" +
+				"001,Swim,0,,,3,10,#000000,,0,0,false\n",
+			"Checkmarks.csv": "Date,Swim,\n2020-11-20,YES_MANUAL,\n",
+		})
+		loop, _ := importer.Get("loop")
+		read, err := loop.Read(dir, importer.Options{})
+
+		if err != nil || len(read) != 1 {
+			t.Fatalf("expected the habit to be read, got %+v (%v)", read, err)
+		}
+
+		result, err := habits.importHabits(read, false)
+
+		if err != nil || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Swim: the frequency of 3 times in 10 days is not supported") {
+			t.Errorf("expected the frequency to be reported, got %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("imports a numeric name with a suffix", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		days := []importer.Day{{Date: day, StepsCount: 1, CheckedSteps: 1, Minutes: 30}}
+
+		if _, err := habits.importHabits([]importer.Habit{{Name: "2024", StepsCount: 1, StepMinutes: 30, Days: days}}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		if name := habits.Habits[0].Name; name != "2024 habit" {
+			t.Errorf("expected the name not to be numeric, got %q", name)
+		}
+	})
+
+	t.Run("saves nothing on a dry run", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		dir := writeLoopExport(t)
+
+		if err := habits.Execute(command.NewCommand("import loop " + dir + " --dry-run")); err != nil {
+			t.Fatal(err)
+		}
+
+		reloaded := newLoopHabits(t)
+		reloaded.Load(habits.Path())
+
+		if len(habits.Habits) != 0 || len(reloaded.Habits) != 0 {
+			t.Errorf("expected no habits, got %+v and %+v", habits.Habits, reloaded.Habits)
+		}
+
+		if err := habits.Execute(command.NewCommand("undo")); err == nil {
+			t.Errorf("expected nothing to be undone")
+		}
+	})
+
+	t.Run("merges into a tracked habit", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		habits.Execute(command.NewCommand("a read 1 30"))
+
+		result, err := habits.importHabits([]importer.Habit{{Name: "READ", Days: []importer.Day{{Date: dayAfter(1), StepsCount: 1, CheckedSteps: 1, Minutes: 15}}}}, false)
+
+		if err != nil || len(result.Created) != 0 || result.Added != 1 || len(habits.Habits) != 1 {
+			t.Fatalf("expected the day to be added to the tracked habit, got %+v (%v)", result, err)
+		}
+
+		if entry := habits.Habits[0].Summary.History[0]; entry.StepMinutes != 15 || entry.CheckedSteps != 1 {
+			t.Errorf("expected the step time of the day, got %+v", entry)
+		}
+	})
+
+	t.Run("clamps the minutes which do not fit the checked steps", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		days := []importer.Day{
+			{Date: day, StepsCount: 1, CheckedSteps: 2, Minutes: 1},
+			{Date: dayAfter(1), StepsCount: 1, CheckedSteps: 1, Minutes: 40000},
+		}
+
+		result, err := habits.importHabits([]importer.Habit{{Name: "Read", StepsCount: 1, StepMinutes: 30, Days: days}}, false)
+
+		if err != nil || result.Added != 2 || len(result.Conflicts) != 2 || !strings.Contains(result.Conflicts[0], "1 min which do not fit 2 checked steps") {
+			t.Fatalf("expected both days to be reported, got %+v (%v)", result, err)
+		}
+
+		if history := habits.Habits[0].Summary.History; history[0].StepMinutes != 1 || history[1].StepMinutes != MaxHabitTotalTime {
+			t.Errorf("expected the step times to be clamped, got %+v", history)
+		}
+	})
+
+	t.Run("rejects an invalid step time", func(t *testing.T) {
+		habits := newLoopHabits(t)
+		err := habits.Execute(command.NewCommand("import loop " + writeLoopExport(t) + " --step-minutes 0"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
+
+func TestGetImportedName(t *testing.T) {
+	if name := getImportedName(" Read, write "); name != "Read write" {
+		t.Errorf("expected the comma to be replaced, got %q", name)
+	}
+
+	if name := getImportedName("12345678901234567890"); name != "1234567890 habit" {
+		t.Errorf("expected a numeric name to be shortened and suffixed, got %q", name)
+	}
+}
@@ -0,0 +1,226 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+// CurrentVersion is the version of the data file written by Save.
+const CurrentVersion = 3
+
+// document is a data file decoded without the Go structs, so a migration
+// does not depend on the current shape of Habit, Summary or Entry.
+type document = map[string]any
+
+// migrations[i] upgrades a document from version i to version i+1.
+var migrations = []func(doc document) error{
+	migrateFixedHistory,
+	migrateIDs,
+	migrateTimeZone,
+}
+
+func getVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+
+	return header.Version, nil
+}
+
+// migrate upgrades the data file to the CurrentVersion and returns its original version.
+func migrate(data []byte) ([]byte, int, error) {
+	version, err := getVersion(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("data file version %d is newer than the supported version %d, update the tracker", version, CurrentVersion)
+	}
+
+	if version == CurrentVersion {
+		return data, version, nil
+	}
+
+	doc, err := decodeDocument(data)
+
+	if err != nil {
+		return nil, version, err
+	}
+
+	for idx := version; idx < CurrentVersion; idx++ {
+		if err := migrations[idx](doc); err != nil {
+			return nil, version, fmt.Errorf("migration to version %d has failed: %w", idx+1, err)
+		}
+
+		doc["version"] = idx + 1
+	}
+
+	migrated, err := json.Marshal(doc)
+
+	return migrated, version, err
+}
+
+func decodeDocument(data []byte) (document, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	doc := document{}
+	err := decoder.Decode(&doc)
+
+	return doc, err
+}
+
+func getObjects(value any) []document {
+	items, _ := value.([]any)
+	objects := []document{}
+
+	for _, item := range items {
+		if object, ok := item.(document); ok {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects
+}
+
+func getInt(object document, key string) int {
+	number, _ := object[key].(json.Number)
+	value, _ := number.Int64()
+
+	return int(value)
+}
+
+func getBool(object document, key string) bool {
+	value, _ := object[key].(bool)
+	return value
+}
+
+func getTotalMinutes(value any) int {
+	totalTime, _ := value.(document)
+	return (getInt(totalTime, "Days")*24+getInt(totalTime, "Hours"))*60 + getInt(totalTime, "Minutes")
+}
+
+func newTotalTime(minutes int) document {
+	return document{"Days": minutes / (24 * 60), "Hours": minutes / 60 % 24, "Minutes": minutes % 60}
+}
+
+// migrateFixedHistory converts the fixed six-day history, where the last item was the day
+// before UpdatedAt, into dated entries. Statistics of the days which had already left
+// the window are kept in the Baseline.
+func migrateFixedHistory(doc document) error {
+	updatedAtStr, _ := doc["UpdatedAt"].(string)
+	updatedAt, err := time.Parse(time.RFC3339Nano, updatedAtStr)
+
+	if err != nil {
+		return fmt.Errorf("invalid UpdatedAt: %w", err)
+	}
+
+	year, month, day := updatedAt.Date()
+	lastDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	for _, habit := range getObjects(doc["Habits"]) {
+		summary, _ := habit["Summary"].(document)
+		legacy := getObjects(summary["History"])
+
+		isLegacy := false
+		for _, entry := range legacy {
+			if _, ok := entry["Date"]; !ok {
+				isLegacy = true
+			}
+		}
+
+		if summary == nil || !isLegacy {
+			continue
+		}
+
+		stepMinutes := getInt(habit, "StepMinutes")
+		history := []any{}
+		windowMinutes, streak, isWindowStreak := 0, 0, true
+
+		for idx, entry := range legacy {
+			stepsCount, checkedSteps, isFrozen := getInt(entry, "StepsCount"), getInt(entry, "CheckedSteps"), getBool(entry, "IsFrozen")
+
+			if stepsCount == 0 && !isFrozen {
+				// The habit did not exist on that day
+				continue
+			}
+
+			entryMinutes := stepMinutes
+			switch {
+			case isFrozen:
+				entryMinutes = 0
+			case checkedSteps >= stepsCount:
+				windowMinutes += stepMinutes * checkedSteps
+				streak += 1
+			default:
+				windowMinutes += stepMinutes * checkedSteps
+				streak = 0
+				isWindowStreak = false
+			}
+
+			history = append(history, document{
+				"Date":         lastDate.AddDate(0, 0, idx-len(legacy)).Format(time.RFC3339Nano),
+				"StepsCount":   stepsCount,
+				"StepMinutes":  entryMinutes,
+				"CheckedSteps": checkedSteps,
+				"IsFrozen":     isFrozen,
+				"IsOffDay":     false,
+				"WeeklyTimes":  0,
+			})
+		}
+
+		baselineStreak := 0
+		if isWindowStreak {
+			baselineStreak = max(0, getInt(summary, "CurrentStreak")-streak)
+		}
+
+		summary["History"] = history
+		summary["Baseline"] = document{
+			"TotalTime":     newTotalTime(max(0, getTotalMinutes(summary["TotalTime"])-windowMinutes)),
+			"LongestStreak": getInt(summary, "LongestStreak"),
+			"CurrentStreak": baselineStreak,
+		}
+	}
+
+	return nil
+}
+
+// migrateIDs gives an ID to every habit.
+func migrateIDs(doc document) error {
+	habits := getObjects(doc["Habits"])
+	nextID := max(1, getInt(doc, "NextID"))
+
+	for _, habit := range habits {
+		nextID = max(nextID, getInt(habit, "ID")+1)
+	}
+
+	for _, habit := range habits {
+		if getInt(habit, "ID") == 0 {
+			habit["ID"] = nextID
+			nextID += 1
+		}
+	}
+
+	doc["NextID"] = nextID
+
+	return nil
+}
+
+// migrateTimeZone counts the days of older files in the local time zone, as they have been counted before.
+func migrateTimeZone(doc document) error {
+	if _, ok := doc["TimeZone"]; !ok {
+		doc["TimeZone"] = utils.GetLocalTimeZone()
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestMigrations applies every migration step to the testdata/migrations/<name>.v<N>.json files
+// and compares the result with the <name>.v<N+1>.json golden files.
+func TestMigrations(t *testing.T) {
+	// The local time zone is stored by a migration
+	t.Setenv("TZ", "Europe/Warsaw")
+
+	for version := range migrations {
+		inputs, err := filepath.Glob(filepath.Join("testdata", "migrations", fmt.Sprintf("*.v%d.json", version)))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, input := range inputs {
+			name := strings.TrimSuffix(filepath.Base(input), fmt.Sprintf(".v%d.json", version))
+
+			t.Run(fmt.Sprintf("%s from v%d to v%d", name, version, version+1), func(t *testing.T) {
+				data, err := os.ReadFile(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				doc, err := decodeDocument(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := migrations[version](doc); err != nil {
+					t.Fatalf("migration has failed: %v", err)
+				}
+				doc["version"] = version + 1
+
+				got, err := json.MarshalIndent(doc, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, '\n')
+
+				golden := filepath.Join("testdata", "migrations", fmt.Sprintf("%s.v%d.json", name, version+1))
+				if *update {
+					os.WriteFile(golden, got, 0644)
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(got, want) {
+					t.Errorf("invalid migration result, expected:\n%s\ngot:\n%s", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+
+	t.Run("does not change a file of the current version", func(t *testing.T) {
+		data := []byte(fmt.Sprintf(`{"version":%d,"Habits":[]}`, CurrentVersion))
+		migrated, version, err := migrate(data)
+
+		if err != nil || version != CurrentVersion || !bytes.Equal(migrated, data) {
+			t.Errorf("file has been changed: %s, version %d (%v)", migrated, version, err)
+		}
+	})
+
+	t.Run("runs the whole chain", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "migrations", "legacy.v0.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		migrated, version, err := migrate(data)
+		if err != nil || version != 0 {
+			t.Fatalf("expected version 0, got %d (%v)", version, err)
+		}
+
+		migratedVersion, _ := getVersion(migrated)
+		if migratedVersion != CurrentVersion {
+			t.Errorf("expected version %d, got %d", CurrentVersion, migratedVersion)
+		}
+	})
+
+	t.Run("fails on an invalid UpdatedAt", func(t *testing.T) {
+		if _, _, err := migrate([]byte(`{"Habits":[],"UpdatedAt":"yesterday"}`)); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package habits
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+var ReportPeriods = []string{"week", "month", "year"}
+var ReportFormats = []string{"table", "markdown", "json"}
+
+// periodStats summarizes the days of a habit in a period. The current day counts as hit once it is fulfilled,
+// but never as missed since it has not been closed yet.
+type periodStats struct {
+	Hits           int   `json:"hits"`
+	Missed         int   `json:"missed"`
+	Frozen         int   `json:"frozen"`
+	Minutes        int   `json:"minutes"`
+	PlannedMinutes int   `json:"plannedMinutes"` // StepsCount*StepMinutes of the due days
+	StreakStart    int16 `json:"streakStart"`    // current streak before the first day of the period
+	StreakEnd      int16 `json:"streakEnd"`      // current streak after the last closed day of the period
+}
+
+// CompletionRate is the share of the due days which have been hit, false when no day was due.
+func (s periodStats) CompletionRate() (float64, bool) {
+	if s.Hits+s.Missed == 0 {
+		return 0, false
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Missed), true
+}
+
+func (s periodStats) stringifyRate() string {
+	if rate, ok := s.CompletionRate(); ok {
+		return fmt.Sprintf("%.0f%%", rate*100)
+	}
+
+	return "-"
+}
+
+type habitReport struct {
+	Habit    Habit
+	Current  periodStats
+	Previous periodStats
+}
+
+// getPeriod returns the first and the last day of the week, month or year which is offset periods from today.
+func getPeriod(period string, today time.Time, offset int) (time.Time, time.Time) {
+	switch period {
+	case "week":
+		start := utils.GetBeginningOfWeekDate(today).AddDate(0, 0, 7*offset)
+		return start, start.AddDate(0, 0, 6)
+	case "month":
+		start := time.Date(today.Year(), today.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	default:
+		start := time.Date(today.Year()+offset, 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	}
+}
+
+// getStreakAt recalculates the current streak of the habit from the closed days up to the date.
+func getStreakAt(habit Habit, date time.Time) int16 {
+	idx := 0
+	for idx < len(habit.Summary.History) && !habit.Summary.History[idx].Date.After(date) {
+		idx++
+	}
+
+	habit.Summary.History = habit.Summary.History[:idx]
+	habit.recalculate()
+
+	return habit.Summary.CurrentStreak
+}
+
+func (h *Habits) getPeriodStats(habit Habit, start time.Time, end time.Time) (periodStats, bool) {
+	stats := periodStats{StreakStart: getStreakAt(habit, start.AddDate(0, 0, -1)), StreakEnd: getStreakAt(habit, end)}
+	// The whole weeks are read, so the quota of a ScheduleTimesPerWeek habit is known at the edges of the period
+	history := h.getHistory(habit, utils.GetBeginningOfWeekDate(start), utils.GetBeginningOfWeekDate(end).AddDate(0, 0, 6))
+	weeks := getWeeksProgress(history)
+	weeklyDays := map[time.Time]int{}
+	today := h.today(&habit)
+	hasDays := false
+
+	for _, entry := range slices.Concat(history, []Entry{habit.getCurrentEntry(today)}) {
+		if entry.Date.Before(start) || entry.Date.After(end) {
+			continue
+		}
+
+		hasDays = true
+
+		if entry.IsFrozen {
+			stats.Frozen += 1
+			continue
+		}
+
+		stats.Minutes += entry.Minutes()
+
+		switch {
+		case entry.IsFulfilled() && !entry.IsOffDay:
+			stats.Hits += 1
+		case entry.Date.Before(today) && entry.isMissed(weeks):
+			stats.Missed += 1
+		}
+
+		// The goal of a ScheduleTimesPerWeek habit is planned for its weekly quota of days
+		switch {
+		case entry.WeeklyTimes > 0:
+			week := utils.GetBeginningOfWeekDate(entry.Date)
+			if weeklyDays[week] < int(entry.WeeklyTimes) {
+				stats.PlannedMinutes += int(entry.StepsCount) * int(entry.StepMinutes)
+			}
+			weeklyDays[week] += 1
+		case !entry.IsOffDay:
+			stats.PlannedMinutes += int(entry.StepsCount) * int(entry.StepMinutes)
+		}
+	}
+
+	return stats, hasDays
+}
+
+// getReports returns the statistics of the habits which have days in the period, with the ones of the previous period.
+func (h *Habits) getReports(period string, offset int) ([]habitReport, time.Time, time.Time) {
+	start, end := getPeriod(period, h.today(nil), offset)
+	previousStart, previousEnd := getPeriod(period, h.today(nil), offset-1)
+	reports := []habitReport{}
+
+	for _, habit := range h.Habits {
+		current, ok := h.getPeriodStats(habit, start, end)
+
+		if !ok {
+			continue
+		}
+
+		previous, _ := h.getPeriodStats(habit, previousStart, previousEnd)
+		reports = append(reports, habitReport{Habit: habit, Current: current, Previous: previous})
+	}
+
+	return reports, start, end
+}
+
+func stringifyComparison(current periodStats, previous periodStats) string {
+	minutes := fmt.Sprintf("%+d min", current.Minutes-previous.Minutes)
+	currentRate, ok := current.CompletionRate()
+	previousRate, previousOk := previous.CompletionRate()
+
+	if !ok || !previousOk {
+		return minutes
+	}
+
+	return fmt.Sprintf("%+.0f pp, %s", (currentRate-previousRate)*100, minutes)
+}
+
+type reportJSON struct {
+	Period string            `json:"period"`
+	Start  string            `json:"start"`
+	End    string            `json:"end"`
+	Habits []habitReportJSON `json:"habits"`
+}
+
+type periodStatsJSON struct {
+	periodStats
+	CompletionRate *float64 `json:"completionRate"` // null when no day was due
+}
+
+type habitReportJSON struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	Current  periodStatsJSON `json:"current"`
+	Previous periodStatsJSON `json:"previous"`
+}
+
+func newPeriodStatsJSON(stats periodStats) periodStatsJSON {
+	result := periodStatsJSON{periodStats: stats}
+
+	if rate, ok := stats.CompletionRate(); ok {
+		result.CompletionRate = &rate
+	}
+
+	return result
+}
+
+// WriteReport writes the report of the week, month or year which is offset periods from the current one,
+// e.g. -1 for the previous one, in one of ReportFormats.
+func (h *Habits) WriteReport(w io.Writer, period string, offset int, format string) error {
+	reports, start, end := h.getReports(period, offset)
+
+	if format == "json" {
+		result := reportJSON{Period: period, Start: start.Format(time.DateOnly), End: end.Format(time.DateOnly), Habits: []habitReportJSON{}}
+
+		for _, report := range reports {
+			result.Habits = append(result.Habits, habitReportJSON{
+				ID:       report.Habit.ID,
+				Name:     report.Habit.Name,
+				Current:  newPeriodStatsJSON(report.Current),
+				Previous: newPeriodStatsJSON(report.Previous),
+			})
+		}
+
+		data, err := json.MarshalIndent(result, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, string(data))
+
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetTitle(fmt.Sprintf("%s%s %s - %s", strings.ToUpper(period[:1]), period[1:], start.Format(time.DateOnly), end.Format(time.DateOnly)))
+	t.AppendHeader(table.Row{"#", "Name", "Done", "Hit", "Missed", "Frozen", "Minutes / Planned", "Streak", "Vs Previous"})
+
+	total := periodStats{}
+
+	for _, report := range reports {
+		stats := report.Current
+		t.AppendRow(table.Row{
+			report.Habit.ID,
+			report.Habit.Name,
+			stats.stringifyRate(),
+			stats.Hits,
+			stats.Missed,
+			stats.Frozen,
+			fmt.Sprintf("%d / %d", stats.Minutes, stats.PlannedMinutes),
+			fmt.Sprintf("%d → %d", stats.StreakStart, stats.StreakEnd),
+			stringifyComparison(stats, report.Previous),
+		})
+
+		total.Hits, total.Missed, total.Frozen = total.Hits+stats.Hits, total.Missed+stats.Missed, total.Frozen+stats.Frozen
+		total.Minutes, total.PlannedMinutes = total.Minutes+stats.Minutes, total.PlannedMinutes+stats.PlannedMinutes
+	}
+
+	t.AppendFooter(table.Row{"", "Total", total.stringifyRate(), total.Hits, total.Missed, total.Frozen, fmt.Sprintf("%d / %d", total.Minutes, total.PlannedMinutes), "", ""})
+
+	if format == "markdown" {
+		fmt.Fprintln(w, t.RenderMarkdown())
+	} else {
+		fmt.Fprintln(w, t.Render())
+	}
+
+	return nil
+}
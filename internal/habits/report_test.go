@@ -0,0 +1,136 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+// newReportHabits tracks Read, 2 steps of 30 minutes daily, fulfilled every day of the week from Monday 2020-11-09.
+// In the current week it is fulfilled on Monday, missed on Tuesday, fulfilled on Wednesday, frozen on Thursday
+// and fulfilled today, Friday 2020-11-20. Run, 3 times per week, is only done on Monday.
+func newReportHabits(t *testing.T) *Habits {
+	return newHabitsWith(t,
+		habitSpec{name: "Read", stepsCount: 2, stepMinutes: 30, history: "2222222" + "212f", checkedSteps: 2},
+		habitSpec{name: "Run", stepsCount: 1, stepMinutes: 45, schedule: Schedule{Kind: ScheduleTimesPerWeek, Times: 3}, history: "1000"},
+	)
+}
+
+func TestGetPeriod(t *testing.T) {
+	var tests = []struct {
+		period string
+		offset int
+		start  string
+		end    string
+	}{
+		{"week", 0, "2020-11-16", "2020-11-22"},
+		{"week", -2, "2020-11-02", "2020-11-08"},
+		{"month", 0, "2020-11-01", "2020-11-30"},
+		{"month", -11, "2019-12-01", "2019-12-31"},
+		{"year", -1, "2019-01-01", "2019-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end := getPeriod(tt.period, day, tt.offset)
+
+			if start.Format(time.DateOnly) != tt.start || end.Format(time.DateOnly) != tt.end {
+				t.Errorf("expected %s - %s, got %s - %s", tt.start, tt.end, start.Format(time.DateOnly), end.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestGetReports(t *testing.T) {
+	t.Run("summarizes the current week", func(t *testing.T) {
+		habits := newReportHabits(t)
+		reports, _, _ := habits.getReports("week", 0)
+
+		expected := periodStats{Hits: 3, Missed: 1, Frozen: 1, Minutes: 210, PlannedMinutes: 240, StreakStart: 7, StreakEnd: 1}
+		if len(reports) != 2 || reports[0].Current != expected {
+			t.Fatalf("expected %+v, got %+v", expected, reports)
+		}
+
+		previous := periodStats{Hits: 7, Minutes: 420, PlannedMinutes: 420, StreakStart: 0, StreakEnd: 7}
+		if reports[0].Previous != previous {
+			t.Errorf("expected the previous week %+v, got %+v", previous, reports[0].Previous)
+		}
+
+		if comparison := stringifyComparison(reports[0].Current, reports[0].Previous); comparison != "-25 pp, -210 min" {
+			t.Errorf("expected the completion and the minutes to be compared, got %q", comparison)
+		}
+	})
+
+	t.Run("plans the weekly quota of a habit scheduled times per week", func(t *testing.T) {
+		habits := newReportHabits(t)
+		reports, _, _ := habits.getReports("week", 0)
+
+		expected := periodStats{Hits: 1, Minutes: 45, PlannedMinutes: 135, StreakStart: 0, StreakEnd: 1}
+		if reports[1].Current != expected {
+			t.Errorf("expected the open week not to be missed, got %+v", reports[1].Current)
+		}
+	})
+
+	t.Run("skips the habits without days in the period", func(t *testing.T) {
+		habits := newReportHabits(t)
+		reports, start, _ := habits.getReports("week", -1)
+
+		if len(reports) != 1 || reports[0].Habit.Name != "Read" || !start.Equal(dayAfter(-11)) {
+			t.Errorf("expected only Read in the week of %s, got %+v", start, reports)
+		}
+	})
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Run("writes JSON", func(t *testing.T) {
+		habits := newReportHabits(t)
+		var buf bytes.Buffer
+		habits.WriteReport(&buf, "month", 0, "json")
+
+		var report struct {
+			Period string
+			Start  string
+			Habits []struct {
+				Name     string
+				Current  struct{ CompletionRate *float64 }
+				Previous struct{ CompletionRate *float64 }
+			}
+		}
+
+		if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+
+		if report.Period != "month" || report.Start != "2020-11-01" || len(report.Habits) != 2 {
+			t.Fatalf("expected the report of November, got %+v", report)
+		}
+
+		if rate := report.Habits[0].Current.CompletionRate; rate == nil || *rate != 10.0/11 || report.Habits[0].Previous.CompletionRate != nil {
+			t.Errorf("expected the completion rate of November and none of October, got %+v", report.Habits[0])
+		}
+	})
+
+	t.Run("writes a Markdown table", func(t *testing.T) {
+		habits := newReportHabits(t)
+		var buf bytes.Buffer
+		habits.WriteReport(&buf, "week", 0, "markdown")
+
+		expected := "| 1 | Read | 75% | 3 | 1 | 1 | 210 / 240 | 7 → 1 | -25 pp, -210 min |"
+		if !strings.Contains(buf.String(), expected) || !strings.Contains(buf.String(), "| Total | 80% | 4 | 1 | 1 | 255 / 375 |") {
+			t.Errorf("expected the rows of Read and the total, got\n%s", buf.String())
+		}
+	})
+
+	t.Run("rejects an unknown period", func(t *testing.T) {
+		habits := newReportHabits(t)
+		err := habits.Execute(command.NewCommand("report day"))
+
+		if _, ok := err.(command.UsageError); !ok {
+			t.Errorf("expected a usage error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package habits
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+type ScheduleKind int8
+
+const (
+	ScheduleDaily ScheduleKind = iota
+	ScheduleWeekdays
+	ScheduleInterval
+	ScheduleTimesPerWeek
+)
+
+const MaxScheduleInterval int16 = 365 // days
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+type Schedule struct {
+	Kind     ScheduleKind
+	Weekdays []time.Weekday // ScheduleWeekdays
+	Every    int16          // ScheduleInterval, days
+	Start    time.Time      // ScheduleInterval, the first due day
+	Times    int8           // ScheduleTimesPerWeek
+}
+
+// ParseSchedule accepts one of the following formats:
+//
+//	daily        every day
+//	mon,wed,fri  on the given days of the week
+//	3d           every 3 days, starting from the start date
+//	3/w          3 times per week, on any days
+func ParseSchedule(value string, start time.Time) (Schedule, error) {
+	value = strings.ToLower(value)
+	every, everyErr := strconv.Atoi(strings.TrimSuffix(value, "d"))
+
+	switch {
+	case value == "" || value == "daily":
+		return Schedule{Kind: ScheduleDaily}, nil
+
+	case strings.HasSuffix(value, "/w"):
+		times, err := strconv.Atoi(strings.TrimSuffix(value, "/w"))
+
+		if err != nil || times < 1 || times > 7 {
+			return Schedule{}, fmt.Errorf("times per week has to be a number between 1 and 7")
+		}
+
+		return Schedule{Kind: ScheduleTimesPerWeek, Times: int8(times)}, nil
+
+	case strings.HasSuffix(value, "d") && everyErr == nil:
+		if every < 1 || every > int(MaxScheduleInterval) {
+			return Schedule{}, fmt.Errorf("interval has to be a number of days between 1 and %d", MaxScheduleInterval)
+		}
+
+		return Schedule{Kind: ScheduleInterval, Every: int16(every), Start: utils.GetBeginningOfDayDate(start)}, nil
+
+	default:
+		weekdays := []time.Weekday{}
+
+		for _, name := range strings.Split(value, ",") {
+			weekday := slices.Index(weekdayNames, name)
+
+			if weekday < 0 {
+				return Schedule{}, fmt.Errorf("unknown schedule %q", value)
+			}
+
+			if !slices.Contains(weekdays, time.Weekday(weekday)) {
+				weekdays = append(weekdays, time.Weekday(weekday))
+			}
+		}
+
+		slices.Sort(weekdays)
+
+		return Schedule{Kind: ScheduleWeekdays, Weekdays: weekdays}, nil
+	}
+}
+
+func (s Schedule) String() string {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		names := make([]string, len(s.Weekdays))
+
+		for idx, weekday := range s.Weekdays {
+			names[idx] = weekdayNames[weekday]
+		}
+
+		return strings.Join(names, ",")
+	case ScheduleInterval:
+		return fmt.Sprintf("%dd", s.Every)
+	case ScheduleTimesPerWeek:
+		return fmt.Sprintf("%d/w", s.Times)
+	default:
+		return "daily"
+	}
+}
+
+// IsDue tells whether the habit has to be done on the given day.
+// Every day is due for ScheduleTimesPerWeek, the weekly quota is evaluated by the Summary.
+func (s Schedule) IsDue(date time.Time) bool {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		return slices.Contains(s.Weekdays, date.Weekday())
+	case ScheduleInterval:
+		daysDiff := utils.GetDaysDiff(s.Start, date)
+		return daysDiff >= 0 && daysDiff%int32(s.Every) == 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,95 @@
+package habits
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	start := time.Date(2020, 11, 20, 12, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		value string
+		want  Schedule
+	}{
+		{"", Schedule{Kind: ScheduleDaily}},
+		{"daily", Schedule{Kind: ScheduleDaily}},
+		{"fri,Mon,wed", Schedule{Kind: ScheduleWeekdays, Weekdays: []time.Weekday{time.Monday, time.Wednesday, time.Friday}}},
+		{"wed", Schedule{Kind: ScheduleWeekdays, Weekdays: []time.Weekday{time.Wednesday}}},
+		{"3d", Schedule{Kind: ScheduleInterval, Every: 3, Start: time.Date(2020, 11, 20, 0, 0, 0, 0, time.UTC)}},
+		{"3/w", Schedule{Kind: ScheduleTimesPerWeek, Times: 3}},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("parses a schedule", func(t *testing.T) {
+			got, err := ParseSchedule(tt.value, start)
+
+			isEqual := got.Kind == tt.want.Kind &&
+				slices.Equal(got.Weekdays, tt.want.Weekdays) &&
+				got.Every == tt.want.Every &&
+				got.Start == tt.want.Start &&
+				got.Times == tt.want.Times
+
+			if err != nil || !isEqual {
+				t.Errorf("invalid schedule for %q, expected: %+v, got: %+v (%v)", tt.value, tt.want, got, err)
+			}
+		})
+	}
+
+	for _, value := range []string{"0d", "8/w", "0/w", "mon,someday", "weekly"} {
+
+		t.Run("returns an error for an invalid schedule", func(t *testing.T) {
+			_, err := ParseSchedule(value, start)
+
+			if err == nil {
+				t.Errorf("expected an error for %q", value)
+			}
+		})
+	}
+}
+
+func TestScheduleString(t *testing.T) {
+
+	for _, value := range []string{"daily", "mon,wed,fri", "3d", "3/w"} {
+
+		t.Run("stringifies a schedule", func(t *testing.T) {
+			schedule, _ := ParseSchedule(value, time.Now())
+
+			if schedule.String() != value {
+				t.Errorf("invalid schedule string, expected: %s, got: %s", value, schedule.String())
+			}
+		})
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	friday := time.Date(2020, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		schedule string
+		date     time.Time
+		want     bool
+	}{
+		{"daily", friday, true},
+		{"mon,fri", friday, true},
+		{"mon,fri", friday.AddDate(0, 0, 1), false},
+		{"3d", friday, true},
+		{"3d", friday.AddDate(0, 0, 2), false},
+		{"3d", friday.AddDate(0, 0, 6), true},
+		{"3d", friday.AddDate(0, 0, -3), false},
+		{"3/w", friday, true},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("tells whether a habit is due", func(t *testing.T) {
+			schedule, _ := ParseSchedule(tt.schedule, friday)
+
+			if schedule.IsDue(tt.date) != tt.want {
+				t.Errorf("expected %s to be due on %s: %t", tt.schedule, tt.date.Weekday(), tt.want)
+			}
+		})
+	}
+}
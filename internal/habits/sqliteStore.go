@@ -0,0 +1,663 @@
+package habits
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite" // pure Go driver, no cgo is needed
+
+	"github.com/seektor/habits-tracker-go/internal/storage"
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	id            INTEGER PRIMARY KEY CHECK (id = 1),
+	version       INTEGER NOT NULL,
+	revision      INTEGER NOT NULL,
+	next_id       INTEGER NOT NULL,
+	updated_at    TEXT NOT NULL,
+	time_zone     TEXT NOT NULL,
+	day_starts_at INTEGER NOT NULL,
+	event_seq     INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS habits (
+	id            INTEGER PRIMARY KEY,
+	name          TEXT NOT NULL,
+	created_at    TEXT NOT NULL,
+	steps_count   INTEGER NOT NULL,
+	step_minutes  INTEGER NOT NULL,
+	checked_steps INTEGER NOT NULL,
+	is_frozen     INTEGER NOT NULL,
+	schedule      TEXT NOT NULL,
+	day_starts_at INTEGER,
+	baseline      TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS entries (
+	habit_id      INTEGER NOT NULL,
+	date          TEXT NOT NULL,
+	steps_count   INTEGER NOT NULL,
+	step_minutes  INTEGER NOT NULL,
+	checked_steps INTEGER NOT NULL,
+	is_frozen     INTEGER NOT NULL,
+	is_off_day    INTEGER NOT NULL,
+	weekly_times  INTEGER NOT NULL,
+	PRIMARY KEY (habit_id, date)
+) WITHOUT ROWID;
+`
+
+const entryColumns = "date, steps_count, step_minutes, checked_steps, is_frozen, is_off_day, weekly_times"
+
+// habitRow is a habit without its history as it is stored in the database.
+// The summary is not stored, it is recalculated on load.
+type habitRow struct {
+	ID           int
+	Name         string
+	CreatedAt    string
+	StepsCount   int8
+	StepMinutes  int16
+	CheckedSteps int8
+	IsFrozen     bool
+	Schedule     string // JSON
+	DayStartsAt  sql.NullInt16
+	Baseline     string // JSON
+}
+
+// savedHabit is a habit as it has been loaded or saved, to find what has changed since.
+type savedHabit struct {
+	row     habitRow
+	history []Entry
+}
+
+// sqliteStore keeps the habits in a SQLite database with a row per day of the history,
+// so a save writes only the habits and the days which have changed.
+type sqliteStore struct {
+	path  string
+	db    *sql.DB
+	saved map[int]savedHabit // nil until the habits are loaded or saved
+}
+
+// execer runs the statements either in a transaction or directly on the database.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func newHabitRow(habit Habit) (habitRow, error) {
+	schedule, err := json.Marshal(habit.Schedule)
+
+	if err != nil {
+		return habitRow{}, err
+	}
+
+	baseline, err := json.Marshal(habit.Summary.Baseline)
+
+	if err != nil {
+		return habitRow{}, err
+	}
+
+	row := habitRow{
+		ID:           habit.ID,
+		Name:         habit.Name,
+		CreatedAt:    habit.CreatedAt.Format(time.RFC3339Nano),
+		StepsCount:   habit.StepsCount,
+		StepMinutes:  habit.StepMinutes,
+		CheckedSteps: habit.CheckedSteps,
+		IsFrozen:     habit.IsFrozen,
+		Schedule:     string(schedule),
+		Baseline:     string(baseline),
+	}
+
+	if habit.DayStartsAt != nil {
+		row.DayStartsAt = sql.NullInt16{Int16: int16(*habit.DayStartsAt), Valid: true}
+	}
+
+	return row, nil
+}
+
+func (r habitRow) toHabit() (Habit, error) {
+	createdAt, err := time.Parse(time.RFC3339Nano, r.CreatedAt)
+
+	if err != nil {
+		return Habit{}, err
+	}
+
+	habit := newHabit(r.Name, r.StepsCount, r.StepMinutes, createdAt)
+	habit.ID, habit.CheckedSteps, habit.IsFrozen = r.ID, r.CheckedSteps, r.IsFrozen
+
+	if err := json.Unmarshal([]byte(r.Schedule), &habit.Schedule); err != nil {
+		return Habit{}, err
+	}
+
+	if err := json.Unmarshal([]byte(r.Baseline), &habit.Summary.Baseline); err != nil {
+		return Habit{}, err
+	}
+
+	if r.DayStartsAt.Valid {
+		dayStart := DayStart(r.DayStartsAt.Int16)
+		habit.DayStartsAt = &dayStart
+	}
+
+	return habit, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(row scanner, dest ...any) (Entry, error) {
+	var entry Entry
+	var date string
+
+	if err := row.Scan(append(dest, &date, &entry.StepsCount, &entry.StepMinutes, &entry.CheckedSteps, &entry.IsFrozen, &entry.IsOffDay, &entry.WeeklyTimes)...); err != nil {
+		return Entry{}, err
+	}
+
+	var err error
+	entry.Date, err = time.Parse(time.DateOnly, date)
+
+	return entry, err
+}
+
+// open creates the database and its tables when they do not exist yet.
+func (s *sqliteStore) open() (*sql.DB, error) {
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", s.path+"?_pragma=busy_timeout(5000)")
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database cannot be opened: %w", err)
+	}
+
+	s.db = db
+
+	return db, nil
+}
+
+func (s *sqliteStore) isMissing() bool {
+	_, err := os.Stat(s.path)
+	return s.db == nil && errors.Is(err, os.ErrNotExist)
+}
+
+func (s *sqliteStore) Load(h *Habits) (string, error) {
+	if s.isMissing() {
+		s.saved = map[int]savedHabit{}
+		return "", nil
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return "", err
+	}
+
+	var version, revision int
+	var updatedAt string
+	loaded := *h
+
+	err = db.QueryRow("SELECT version, revision, next_id, updated_at, time_zone, day_starts_at, event_seq FROM meta").
+		Scan(&version, &revision, &loaded.NextID, &updatedAt, &loaded.TimeZone, &loaded.DayStartsAt, &loaded.EventSeq)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		s.saved = map[int]savedHabit{}
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if version > CurrentVersion {
+		return "", fmt.Errorf("database version %d is newer than the supported version %d, update the tracker", version, CurrentVersion)
+	}
+
+	if loaded.UpdatedAt, err = time.Parse(time.RFC3339Nano, updatedAt); err != nil {
+		return "", err
+	}
+
+	if loaded.location, err = time.LoadLocation(loaded.TimeZone); err != nil {
+		return "", fmt.Errorf("invalid time zone %q: %w", loaded.TimeZone, err)
+	}
+
+	if loaded.Habits, err = s.loadHabits(db); err != nil {
+		return "", err
+	}
+
+	saved := map[int]savedHabit{}
+	for idx := range loaded.Habits {
+		habit := &loaded.Habits[idx]
+		habit.recalculate()
+
+		if saved[habit.ID], err = newSavedHabit(*habit); err != nil {
+			return "", err
+		}
+	}
+
+	*h = loaded
+	s.saved = saved
+
+	return strconv.Itoa(revision), nil
+}
+
+func (s *sqliteStore) loadHabits(db *sql.DB) ([]Habit, error) {
+	rows, err := db.Query("SELECT id, name, created_at, steps_count, step_minutes, checked_steps, is_frozen, schedule, day_starts_at, baseline FROM habits ORDER BY id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	habits := []Habit{}
+	indexes := map[int]int{}
+
+	for rows.Next() {
+		var r habitRow
+
+		if err := rows.Scan(&r.ID, &r.Name, &r.CreatedAt, &r.StepsCount, &r.StepMinutes, &r.CheckedSteps, &r.IsFrozen, &r.Schedule, &r.DayStartsAt, &r.Baseline); err != nil {
+			return nil, err
+		}
+
+		habit, err := r.toHabit()
+
+		if err != nil {
+			return nil, fmt.Errorf("habit %d cannot be read: %w", r.ID, err)
+		}
+
+		indexes[habit.ID] = len(habits)
+		habits = append(habits, habit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := db.Query("SELECT habit_id, " + entryColumns + " FROM entries ORDER BY habit_id, date")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer entries.Close()
+
+	for entries.Next() {
+		var habitID int
+		entry, err := scanEntry(entries, &habitID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if idx, ok := indexes[habitID]; ok {
+			habits[idx].Summary.History = append(habits[idx].Summary.History, entry)
+		}
+	}
+
+	return habits, entries.Err()
+}
+
+func newSavedHabit(habit Habit) (savedHabit, error) {
+	row, err := newHabitRow(habit)
+	return savedHabit{row: row, history: append([]Entry{}, habit.Summary.History...)}, err
+}
+
+// backup copies the database to the backups directory next to it, the same way as a JSON data file.
+// A database without saved habits is not backed up.
+func (s *sqliteStore) backup() error {
+	if revision, err := s.Revision(); err != nil || revision == "" {
+		return err
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return err
+	}
+
+	backupPath, err := storage.NewBackupPath(s.path)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec("VACUUM INTO ?", backupPath); err != nil {
+		return err
+	}
+
+	return storage.RemoveOldBackups(s.path, storage.DefaultBackupsCount)
+}
+
+// Save writes the data file settings and, in the same transaction, the habits which have changed since
+// they were loaded or saved. Everything is written when the habits have not been loaded from the database.
+// The previous content is backed up before.
+func (s *sqliteStore) Save(h *Habits) (string, error) {
+	if err := s.backup(); err != nil {
+		return "", fmt.Errorf("backup has failed: %w", err)
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	revision, err := nextRevision(tx)
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`INSERT INTO meta (id, version, revision, next_id, updated_at, time_zone, day_starts_at, event_seq)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET version = excluded.version, revision = excluded.revision, next_id = excluded.next_id,
+			updated_at = excluded.updated_at, time_zone = excluded.time_zone, day_starts_at = excluded.day_starts_at, event_seq = excluded.event_seq`,
+		h.Version, revision, h.NextID, h.UpdatedAt.Format(time.RFC3339Nano), h.TimeZone, h.DayStartsAt, h.EventSeq)
+
+	if err != nil {
+		return "", err
+	}
+
+	previous := s.saved
+	if previous == nil {
+		if _, err := tx.Exec("DELETE FROM entries; DELETE FROM habits"); err != nil {
+			return "", err
+		}
+	}
+
+	current := map[int]savedHabit{}
+	for _, habit := range h.Habits {
+		prev, ok := previous[habit.ID]
+
+		if current[habit.ID], err = saveHabit(tx, habit, prev, ok); err != nil {
+			return "", fmt.Errorf("habit %q cannot be saved: %w", habit.Name, err)
+		}
+	}
+
+	for id := range previous {
+		if _, ok := current[id]; ok {
+			continue
+		}
+
+		if _, err := tx.Exec("DELETE FROM entries WHERE habit_id = ?", id); err != nil {
+			return "", err
+		}
+
+		if _, err := tx.Exec("DELETE FROM habits WHERE id = ?", id); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	s.saved = current
+
+	return strconv.Itoa(revision), nil
+}
+
+func nextRevision(db execer) (int, error) {
+	var revision int
+	err := db.QueryRow("SELECT revision FROM meta").Scan(&revision)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 1, nil
+	}
+
+	return revision + 1, err
+}
+
+// saveHabit writes the habit when its settings have changed and the entries of its history
+// which differ from the previously saved ones.
+func saveHabit(db execer, habit Habit, prev savedHabit, isSaved bool) (savedHabit, error) {
+	current, err := newSavedHabit(habit)
+
+	if err != nil {
+		return savedHabit{}, err
+	}
+
+	if !isSaved || prev.row != current.row {
+		r := current.row
+		_, err := db.Exec(`INSERT OR REPLACE INTO habits (id, name, created_at, steps_count, step_minutes, checked_steps, is_frozen, schedule, day_starts_at, baseline)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.ID, r.Name, r.CreatedAt, r.StepsCount, r.StepMinutes, r.CheckedSteps, r.IsFrozen, r.Schedule, r.DayStartsAt, r.Baseline)
+
+		if err != nil {
+			return savedHabit{}, err
+		}
+	}
+
+	// Days are usually only appended, so the common beginning of the histories is skipped
+	common := 0
+	for common < min(len(prev.history), len(current.history)) && isSameEntry(prev.history[common], current.history[common]) {
+		common += 1
+	}
+
+	dates := map[string]bool{}
+	for _, entry := range current.history[common:] {
+		dates[entry.Date.Format(time.DateOnly)] = true
+
+		if err := saveEntry(db, habit.ID, entry); err != nil {
+			return savedHabit{}, err
+		}
+	}
+
+	for _, entry := range prev.history[common:] {
+		date := entry.Date.Format(time.DateOnly)
+
+		if dates[date] {
+			continue
+		}
+
+		if _, err := db.Exec("DELETE FROM entries WHERE habit_id = ? AND date = ?", habit.ID, date); err != nil {
+			return savedHabit{}, err
+		}
+	}
+
+	return current, nil
+}
+
+func isSameEntry(a, b Entry) bool {
+	if !a.Date.Equal(b.Date) {
+		return false
+	}
+
+	a.Date, b.Date = time.Time{}, time.Time{}
+
+	return a == b
+}
+
+func saveEntry(db execer, habitID int, entry Entry) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO entries (habit_id, "+entryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		habitID, entry.Date.Format(time.DateOnly), entry.StepsCount, entry.StepMinutes, entry.CheckedSteps, entry.IsFrozen, entry.IsOffDay, entry.WeeklyTimes)
+
+	return err
+}
+
+func (s *sqliteStore) Revision() (string, error) {
+	if s.isMissing() {
+		return "", nil
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return "", err
+	}
+
+	var revision int
+	err = db.QueryRow("SELECT revision FROM meta").Scan(&revision)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return strconv.Itoa(revision), err
+}
+
+// update runs the change in a transaction and bumps the revision, so other processes reload the habits.
+// A database without the data file settings gets the default ones.
+func (s *sqliteStore) update(nextID int, change func(tx *sql.Tx) error) error {
+	if err := s.backup(); err != nil {
+		return fmt.Errorf("backup has failed: %w", err)
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO meta (id, version, revision, next_id, updated_at, time_zone, day_starts_at, event_seq)
+		VALUES (1, ?, 1, ?, ?, ?, 0, 0)
+		ON CONFLICT (id) DO UPDATE SET revision = revision + 1, next_id = max(next_id, excluded.next_id)`,
+		CurrentVersion, nextID, time.Now().Format(time.RFC3339Nano), utils.GetLocalTimeZone())
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStore) checkHabit(db execer, habitID int) error {
+	var id int
+	err := db.QueryRow("SELECT id FROM habits WHERE id = ?", habitID).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("habit with id %d does not exist", habitID)
+	}
+
+	return err
+}
+
+func (s *sqliteStore) SaveHabit(habit Habit) error {
+	prev, isSaved := s.saved[habit.ID]
+	var current savedHabit
+
+	err := s.update(habit.ID+1, func(tx *sql.Tx) error {
+		if !isSaved {
+			// The history stored so far is not known, it is replaced as a whole
+			if _, err := tx.Exec("DELETE FROM entries WHERE habit_id = ?", habit.ID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		current, err = saveHabit(tx, habit, prev, isSaved)
+
+		return err
+	})
+
+	if err == nil && s.saved != nil {
+		s.saved[habit.ID] = current
+	}
+
+	return err
+}
+
+func (s *sqliteStore) AppendEntry(habitID int, entry Entry) error {
+	err := s.update(0, func(tx *sql.Tx) error {
+		if err := s.checkHabit(tx, habitID); err != nil {
+			return err
+		}
+
+		return saveEntry(tx, habitID, entry)
+	})
+
+	if saved, ok := s.saved[habitID]; err == nil && ok {
+		saved.history = insertEntry(saved.history, entry)
+		s.saved[habitID] = saved
+	}
+
+	return err
+}
+
+// History reads only the requested days, using the primary key of the entries.
+func (s *sqliteStore) History(habitID int, from, to time.Time) ([]Entry, error) {
+	if s.isMissing() {
+		return nil, fmt.Errorf("habit with id %d does not exist", habitID)
+	}
+
+	db, err := s.open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkHabit(db, habitID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT "+entryColumns+" FROM entries WHERE habit_id = ? AND date BETWEEN ? AND ? ORDER BY date",
+		habitID, from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *sqliteStore) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+
+	return err
+}
@@ -0,0 +1,232 @@
+package habits
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+var StatsWindows = []int{7, 30, 90} // days of the rolling completion rates
+
+const TrendDays = 90          // closed days of the linear trend
+const TrendThreshold = 0.05   // change of the completion in 30 days below which the trend is stable
+const StrengthHalfLife = 14.0 // counted days after which the weight of a day is halved
+
+// completion counts the hit days among the counted ones.
+type completion struct {
+	Hits    int
+	Counted int
+}
+
+func (c *completion) add(score float64) {
+	c.Counted += 1
+	if score >= 1 {
+		c.Hits += 1
+	}
+}
+
+func (c completion) String() string {
+	if c.Counted == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%% (%d/%d)", float64(c.Hits)/float64(c.Counted)*100, c.Hits, c.Counted)
+}
+
+type habitStats struct {
+	Windows        []completion // in the order of StatsWindows
+	Weekdays       [7]completion
+	AverageMinutes float64 // per day which is not frozen
+	Trend          float64 // change of the score per day, NaN when there are not enough days
+	Strength       float64 // from 0 to 1
+}
+
+// getScore returns the share of the goal which has been checked on a closed day. Frozen days, off days
+// and days which are not needed for the weekly quota are not counted, so they neither raise nor lower the score.
+func getScore(entry Entry, weeks map[time.Time]*weekProgress) (float64, bool) {
+	switch {
+	case entry.IsFrozen || entry.IsOffDay:
+		return 0, false
+	case entry.IsFulfilled():
+		return 1, true
+	case entry.isMissed(weeks):
+		return float64(entry.CheckedSteps) / float64(entry.StepsCount), true
+	default:
+		return 0, false
+	}
+}
+
+// getCompletion counts the days from the date on.
+func getCompletion(history []Entry, weeks map[time.Time]*weekProgress, from time.Time) completion {
+	result := completion{}
+
+	for _, entry := range history {
+		if score, ok := getScore(entry, weeks); ok && !entry.Date.Before(from) {
+			result.add(score)
+		}
+	}
+
+	return result
+}
+
+func getWeekdaysCompletion(history []Entry, weeks map[time.Time]*weekProgress) [7]completion {
+	result := [7]completion{}
+
+	for _, entry := range history {
+		if score, ok := getScore(entry, weeks); ok {
+			result[entry.Date.Weekday()].add(score)
+		}
+	}
+
+	return result
+}
+
+// getBestWeekday returns the weekday with the highest completion, the earlier one of the week on a tie.
+func getBestWeekday(weekdays [7]completion) (time.Weekday, bool) {
+	best, bestRate, ok := time.Monday, -1.0, false
+
+	for idx := range 7 {
+		weekday := time.Weekday((idx + 1) % 7)
+		result := weekdays[weekday]
+
+		if result.Counted == 0 {
+			continue
+		}
+
+		if rate := float64(result.Hits) / float64(result.Counted); rate > bestRate {
+			best, bestRate, ok = weekday, rate, true
+		}
+	}
+
+	return best, ok
+}
+
+func getAverageMinutes(history []Entry) float64 {
+	minutes, days := 0, 0
+
+	for _, entry := range history {
+		if !entry.IsFrozen {
+			minutes += entry.Minutes()
+			days += 1
+		}
+	}
+
+	if days == 0 {
+		return 0
+	}
+
+	return float64(minutes) / float64(days)
+}
+
+// getTrend fits a line to the scores of the days from the date on by least squares and returns its slope,
+// the change of the score per day. It is NaN for less than two counted days.
+func getTrend(history []Entry, weeks map[time.Time]*weekProgress, from time.Time) float64 {
+	var n, sumX, sumY, sumXY, sumXX float64
+
+	for _, entry := range history {
+		score, ok := getScore(entry, weeks)
+
+		if !ok || entry.Date.Before(from) {
+			continue
+		}
+
+		x := entry.Date.Sub(from).Hours() / 24
+		n, sumX, sumY, sumXY, sumXX = n+1, sumX+x, sumY+score, sumXY+x*score, sumXX+x*x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+
+	if n < 2 || denominator == 0 {
+		return math.NaN()
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// getStrength is an exponential moving average of the scores, similar to the habit score of Loop Habit Tracker.
+// Every counted day moves the strength towards its score, recent days weigh more than the older ones.
+func getStrength(history []Entry, weeks map[time.Time]*weekProgress) float64 {
+	multiplier := math.Pow(0.5, 1/StrengthHalfLife)
+	strength := 0.0
+
+	for _, entry := range history {
+		if score, ok := getScore(entry, weeks); ok {
+			strength = strength*multiplier + score*(1-multiplier)
+		}
+	}
+
+	return strength
+}
+
+// getStats computes the statistics from the closed days, the current day is not finished yet.
+func (h *Habits) getStats(habit Habit) habitStats {
+	today := h.today(&habit)
+	history := h.getHistory(habit, time.Time{}, today.AddDate(0, 0, -1))
+	weeks := getWeeksProgress(history)
+	stats := habitStats{
+		Weekdays:       getWeekdaysCompletion(history, weeks),
+		AverageMinutes: getAverageMinutes(history),
+		Trend:          getTrend(history, weeks, today.AddDate(0, 0, -TrendDays)),
+		Strength:       getStrength(history, weeks),
+	}
+
+	for _, days := range StatsWindows {
+		stats.Windows = append(stats.Windows, getCompletion(history, weeks, today.AddDate(0, 0, -days)))
+	}
+
+	return stats
+}
+
+func stringifyTrend(trend float64) string {
+	switch {
+	case math.IsNaN(trend):
+		return "-"
+	case trend*30 >= TrendThreshold:
+		return fmt.Sprintf("improving, %+.0f pp per 30 days", trend*30*100)
+	case trend*30 <= -TrendThreshold:
+		return fmt.Sprintf("declining, %+.0f pp per 30 days", trend*30*100)
+	default:
+		return fmt.Sprintf("stable, %+.0f pp per 30 days", trend*30*100)
+	}
+}
+
+// renderStats draws a table of the statistics and a table of the completion by weekday, from Monday.
+func (h *Habits) renderStats(habit Habit) string {
+	stats := h.getStats(habit)
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.SetTitle(habit.Name)
+
+	for idx, days := range StatsWindows {
+		t.AppendRow(table.Row{fmt.Sprintf("Completion, last %d days", days), stats.Windows[idx].String()})
+	}
+
+	if weekday, ok := getBestWeekday(stats.Weekdays); ok {
+		t.AppendRow(table.Row{"Best weekday", fmt.Sprintf("%s, %s", weekday, stats.Weekdays[weekday])})
+	} else {
+		t.AppendRow(table.Row{"Best weekday", "-"})
+	}
+
+	t.AppendRow(table.Row{"Average minutes per day", fmt.Sprintf("%.1f", stats.AverageMinutes)})
+	t.AppendRow(table.Row{fmt.Sprintf("Trend, last %d days", TrendDays), stringifyTrend(stats.Trend)})
+	t.AppendRow(table.Row{"Strength", fmt.Sprintf("%.0f%%", stats.Strength*100)})
+
+	weekdays := table.NewWriter()
+	weekdays.SetStyle(table.StyleLight)
+	header, row := table.Row{}, table.Row{}
+
+	for idx := range 7 {
+		weekday := time.Weekday((idx + 1) % 7)
+		header = append(header, weekday.String()[:3])
+		row = append(row, stats.Weekdays[weekday].String())
+	}
+
+	weekdays.AppendHeader(header)
+	weekdays.AppendRow(row)
+
+	return strings.Join([]string{t.Render(), weekdays.Render()}, "\n")
+}
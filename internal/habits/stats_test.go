@@ -0,0 +1,213 @@
+package habits
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+// newStatsHistory returns daily entries of 2 steps of 30 minutes with the checked steps, the last one yesterday.
+func newStatsHistory(checkedSteps ...int8) []Entry {
+	history := []Entry{}
+
+	for idx, checked := range checkedSteps {
+		history = append(history, Entry{Date: dayAfter(idx - len(checkedSteps)), StepsCount: 2, StepMinutes: 30, CheckedSteps: checked})
+	}
+
+	return history
+}
+
+func TestGetScore(t *testing.T) {
+	// Run, 2 times per week, is done once in the closed week of 2020-11-09 and not yet in the open one
+	weekly := []Entry{}
+	for days := -11; days <= -1; days++ {
+		checked := int8(0)
+		if days == -11 {
+			checked = 1
+		}
+		weekly = append(weekly, Entry{Date: dayAfter(days), StepsCount: 1, CheckedSteps: checked, WeeklyTimes: 2})
+	}
+	weeks := getWeeksProgress(weekly)
+
+	var tests = []struct {
+		name    string
+		entry   Entry
+		score   float64
+		counted bool
+	}{
+		{"does not count a frozen day", Entry{IsFrozen: true}, 0, false},
+		{"does not count an off day", Entry{StepsCount: 2, IsOffDay: true}, 0, false},
+		{"scores a fulfilled day", Entry{StepsCount: 2, CheckedSteps: 3}, 1, true},
+		{"scores the checked share of a missed day", Entry{StepsCount: 4, CheckedSteps: 1}, 0.25, true},
+		{"scores a missed day of a closed week", weekly[1], 0, true},
+		{"does not count a day of an open week", weekly[len(weekly)-1], 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, counted := getScore(tt.entry, weeks)
+
+			if score != tt.score || counted != tt.counted {
+				t.Errorf("expected %v, %v, got %v, %v", tt.score, tt.counted, score, counted)
+			}
+		})
+	}
+}
+
+func TestGetCompletion(t *testing.T) {
+	history := newStatsHistory(0, 2, 2, 1, 2, 2, 2, 2, 2, 2)
+	history[5].IsFrozen = true
+	weeks := getWeeksProgress(history)
+
+	var tests = []struct {
+		days int
+		want completion
+	}{
+		{3, completion{Hits: 3, Counted: 3}},
+		{7, completion{Hits: 5, Counted: 6}},
+		{30, completion{Hits: 7, Counted: 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.String(), func(t *testing.T) {
+			if got := getCompletion(history, weeks, day.AddDate(0, 0, -tt.days)); got != tt.want {
+				t.Errorf("expected %+v in the last %d days, got %+v", tt.want, tt.days, got)
+			}
+		})
+	}
+
+	t.Run("is empty without counted days", func(t *testing.T) {
+		if got := getCompletion(nil, weeks, day).String(); got != "-" {
+			t.Errorf("expected -, got %q", got)
+		}
+	})
+}
+
+func TestGetWeekdaysCompletion(t *testing.T) {
+	// From Wednesday 2020-11-11 to Thursday 2020-11-19, both Wednesdays are missed
+	history := newStatsHistory(0, 2, 2, 2, 2, 2, 2, 0, 2)
+	weekdays := getWeekdaysCompletion(history, getWeeksProgress(history))
+
+	if weekdays[time.Wednesday] != (completion{Hits: 0, Counted: 2}) || weekdays[time.Thursday] != (completion{Hits: 2, Counted: 2}) {
+		t.Errorf("expected Wednesdays to be missed and Thursdays hit, got %+v", weekdays)
+	}
+
+	if best, ok := getBestWeekday(weekdays); !ok || best != time.Monday {
+		t.Errorf("expected Monday to be the earliest best weekday, got %s", best)
+	}
+
+	if _, ok := getBestWeekday([7]completion{}); ok {
+		t.Errorf("expected no best weekday without counted days")
+	}
+}
+
+func TestGetAverageMinutes(t *testing.T) {
+	history := newStatsHistory(2, 1, 0, 3)
+	history[3] = Entry{Date: history[3].Date, IsFrozen: true}
+
+	if got := getAverageMinutes(history); got != 30 {
+		t.Errorf("expected 30 minutes per day which is not frozen, got %v", got)
+	}
+
+	if got := getAverageMinutes(nil); got != 0 {
+		t.Errorf("expected 0 minutes without days, got %v", got)
+	}
+}
+
+func TestGetTrend(t *testing.T) {
+	from := day.AddDate(0, 0, -TrendDays)
+
+	t.Run("detects an improving habit", func(t *testing.T) {
+		history := newStatsHistory(0, 1, 2, 2)
+
+		if trend := getTrend(history, getWeeksProgress(history), from); trend <= 0 || !strings.HasPrefix(stringifyTrend(trend), "improving") {
+			t.Errorf("expected an improving trend, got %v", trend)
+		}
+	})
+
+	t.Run("detects a declining habit", func(t *testing.T) {
+		history := newStatsHistory(2, 2, 1, 0)
+
+		if trend := getTrend(history, getWeeksProgress(history), from); math.Abs(trend+0.35) > 1e-9 {
+			t.Errorf("expected the score to decline by 0.35 per day, got %v", trend)
+		}
+	})
+
+	t.Run("ignores the days before the date", func(t *testing.T) {
+		history := newStatsHistory(0, 0, 2, 2, 2)
+
+		if trend := getTrend(history, getWeeksProgress(history), day.AddDate(0, 0, -3)); trend != 0 || !strings.HasPrefix(stringifyTrend(trend), "stable") {
+			t.Errorf("expected a stable trend, got %v", trend)
+		}
+	})
+
+	t.Run("needs two counted days", func(t *testing.T) {
+		history := newStatsHistory(2)
+
+		if trend := getTrend(history, getWeeksProgress(history), from); !math.IsNaN(trend) || stringifyTrend(trend) != "-" {
+			t.Errorf("expected no trend, got %v", trend)
+		}
+	})
+}
+
+func TestGetStrength(t *testing.T) {
+	t.Run("approaches 1 on a perfect run", func(t *testing.T) {
+		history := newStatsHistory(make([]int8, 100)...)
+		for idx := range history {
+			history[idx].CheckedSteps = 2
+		}
+
+		if strength := getStrength(history, getWeeksProgress(history)); strength < 0.99 || strength > 1 {
+			t.Errorf("expected a strength close to 1, got %v", strength)
+		}
+	})
+
+	t.Run("halves after a half-life of misses", func(t *testing.T) {
+		history := newStatsHistory(make([]int8, 100+StrengthHalfLife)...)
+		for idx := range 100 {
+			history[idx].CheckedSteps = 2
+		}
+		weeks := getWeeksProgress(history)
+		before := getStrength(history[:100], weeks)
+
+		if strength := getStrength(history, weeks); math.Abs(strength-before/2) > 1e-9 {
+			t.Errorf("expected the strength to halve from %v, got %v", before, strength)
+		}
+	})
+
+	t.Run("is not changed by frozen days", func(t *testing.T) {
+		history := newStatsHistory(2, 2, 0)
+		history[2].IsFrozen = true
+		weeks := getWeeksProgress(history)
+
+		if strength := getStrength(history, weeks); strength != getStrength(history[:2], weeks) {
+			t.Errorf("expected the frozen day to be skipped, got %v", strength)
+		}
+	})
+}
+
+func TestStatsCommand(t *testing.T) {
+	t.Run("renders the statistics", func(t *testing.T) {
+		habits := newHabitsWith(t, habitSpec{name: "Read", stepsCount: 2, stepMinutes: 30, history: "0221222222"})
+
+		output := habits.renderStats(habits.Habits[0])
+
+		for _, expected := range []string{"│ Completion, last 7 days  │ 86% (6/7)", "│ Best weekday             │ Monday, 100% (1/1)", "│ Average minutes per day  │ 51.0"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q, got\n%s", expected, output)
+			}
+		}
+	})
+
+	t.Run("rejects an unknown habit", func(t *testing.T) {
+		habits := newHabitsAt(clock.NewFake(day))
+
+		if err := habits.Execute(command.NewCommand("stats read")); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package habits
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/storage"
+)
+
+// Store keeps the habits between the runs of the tracker.
+type Store interface {
+	// Load reads all the habits into h and returns the revision of the saved habits.
+	// h is left untouched when nothing has been saved yet.
+	Load(h *Habits) (string, error)
+	// Save writes the habits and returns their new revision.
+	Save(h *Habits) (string, error)
+	// Revision identifies the saved habits, it changes with every save, also of another process.
+	Revision() (string, error)
+	// SaveHabit adds or replaces a single habit together with its history.
+	SaveHabit(habit Habit) error
+	// AppendEntry adds a closed day to the history of the habit, an entry of the same day is replaced.
+	AppendEntry(habitID int, entry Entry) error
+	// History returns the entries of the habit between the dates, both inclusive, ordered by date.
+	History(habitID int, from, to time.Time) ([]Entry, error)
+	Close() error
+}
+
+var sqliteExtensions = []string{".db", ".sqlite", ".sqlite3"}
+
+// OpenStore returns the store of the data file, a SQLite database for the .db, .sqlite
+// and .sqlite3 extensions and a JSON file otherwise.
+func OpenStore(path string) Store {
+	if slices.Contains(sqliteExtensions, strings.ToLower(filepath.Ext(path))) {
+		return &sqliteStore{path: path}
+	}
+
+	return &jsonStore{path: path}
+}
+
+// getHistory returns the closed days of the habit between the dates, both inclusive. They are read from the store,
+// so a SQLite database reads only those days, unless the habits have changes which have not been saved yet.
+func (h *Habits) getHistory(habit Habit, from, to time.Time) []Entry {
+	if h.store != nil && len(h.pending) == 0 {
+		if history, err := h.store.History(habit.ID, from, to); err == nil {
+			return history
+		}
+	}
+
+	return getEntriesBetween(habit.Summary.History, from, to)
+}
+
+// readBackup returns the content of a JSON data file with the habits of the backup, a SQLite backup is loaded first.
+func readBackup(path string) ([]byte, error) {
+	store, ok := OpenStore(path).(*sqliteStore)
+
+	if !ok {
+		return os.ReadFile(path)
+	}
+
+	defer store.Close()
+	h := NewHabits(clock.System{})
+
+	if _, err := store.Load(h); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(h)
+}
+
+// insertEntry keeps the history ordered by date, an entry of the same day is replaced.
+func insertEntry(history []Entry, entry Entry) []Entry {
+	entry.Date = entry.Date.UTC()
+	idx, found := slices.BinarySearchFunc(history, entry.Date, func(e Entry, date time.Time) int {
+		return e.Date.Compare(date)
+	})
+
+	if found {
+		history[idx] = entry
+		return history
+	}
+
+	return slices.Insert(history, idx, entry)
+}
+
+// getEntriesBetween returns the entries of the ordered history between the dates, both inclusive.
+func getEntriesBetween(history []Entry, from, to time.Time) []Entry {
+	entries := []Entry{}
+
+	for _, entry := range history {
+		if !entry.Date.Before(from) && !entry.Date.After(to) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// jsonStore keeps the habits in a single JSON file, which is rewritten on every save.
+// The previous content is backed up before.
+type jsonStore struct {
+	path string
+}
+
+func getRevision(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// Load migrates a file of an older version to the CurrentVersion, backing up the original first.
+func (s *jsonStore) Load(h *Habits) (string, error) {
+	file, err := os.ReadFile(s.path)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if len(file) > 0 {
+		version, err := getVersion(file)
+
+		if err != nil {
+			return "", err
+		}
+
+		if version < CurrentVersion {
+			if err := storage.CreateVersionBackup(s.path, file, version); err != nil {
+				return "", fmt.Errorf("backup before the migration has failed: %w", err)
+			}
+		}
+
+		if err := h.decode(file); err != nil {
+			return "", err
+		}
+	}
+
+	return getRevision(file), nil
+}
+
+func (s *jsonStore) Save(h *Habits) (string, error) {
+	data, err := json.Marshal(h)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := storage.CreateBackup(s.path, storage.DefaultBackupsCount); err != nil {
+		return "", fmt.Errorf("backup has failed: %w", err)
+	}
+
+	if err := storage.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return "", err
+	}
+
+	return getRevision(data), nil
+}
+
+func (s *jsonStore) Revision() (string, error) {
+	file, err := os.ReadFile(s.path)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	return getRevision(file), nil
+}
+
+// update applies the change to the saved habits and saves them, the whole file is rewritten.
+func (s *jsonStore) update(change func(h *Habits) error) error {
+	h := NewHabits(clock.System{})
+
+	if _, err := s.Load(h); err != nil {
+		return err
+	}
+
+	if err := change(h); err != nil {
+		return err
+	}
+
+	_, err := s.Save(h)
+
+	return err
+}
+
+func (s *jsonStore) SaveHabit(habit Habit) error {
+	return s.update(func(h *Habits) error {
+		habit.recalculate()
+		idx := slices.IndexFunc(h.Habits, func(item Habit) bool { return item.ID == habit.ID })
+
+		if idx >= 0 {
+			h.Habits[idx] = habit
+			return nil
+		}
+
+		h.Habits = append(h.Habits, habit)
+		h.NextID = max(h.NextID, habit.ID+1)
+
+		return nil
+	})
+}
+
+func (s *jsonStore) AppendEntry(habitID int, entry Entry) error {
+	return s.update(func(h *Habits) error {
+		habit, err := h.Get(strconv.Itoa(habitID))
+
+		if err != nil {
+			return err
+		}
+
+		habit.Summary.History = insertEntry(habit.Summary.History, entry)
+		habit.recalculate()
+
+		return nil
+	})
+}
+
+func (s *jsonStore) History(habitID int, from, to time.Time) ([]Entry, error) {
+	h := NewHabits(clock.System{})
+
+	if _, err := s.Load(h); err != nil {
+		return nil, err
+	}
+
+	habit, err := h.Get(strconv.Itoa(habitID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getEntriesBetween(habit.Summary.History, from, to), nil
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
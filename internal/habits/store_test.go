@@ -0,0 +1,295 @@
+package habits
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+var storeNames = []string{"habits.json", "habits.db"}
+
+// newSavedHabits saves two habits with the history of a single day.
+func newSavedHabits(t *testing.T, path string) *Habits {
+	fake := clock.NewFake(day.Add(12 * time.Hour))
+	habits := NewHabits(fake)
+
+	if err := habits.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	habits.Create("Read", 2, 30, Schedule{})
+	habits.Create("Run", 1, 60, Schedule{Kind: ScheduleWeekdays, Weekdays: []time.Weekday{time.Monday}})
+	habits.Habits[0].CheckStep()
+	fake.AdvanceDays(1)
+	habits.UpdateToPresent()
+
+	if err := habits.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	return habits
+}
+
+func TestOpenStore(t *testing.T) {
+	var tests = []struct {
+		path     string
+		isSQLite bool
+	}{
+		{"habits.json", false},
+		{"habits.db", true},
+		{"habits.SQLite", true},
+		{"habits.sqlite3", true},
+		{"habits", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, isSQLite := OpenStore(tt.path).(*sqliteStore)
+
+			if isSQLite != tt.isSQLite {
+				t.Errorf("expected SQLite to be %v, got %v", tt.isSQLite, isSQLite)
+			}
+		})
+	}
+}
+
+func TestStores(t *testing.T) {
+	for _, name := range storeNames {
+
+		t.Run(name+" loads the saved habits", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			saved := newSavedHabits(t, path)
+			saved.Close()
+
+			loaded := NewHabits(clock.NewFake(day))
+			defer loaded.Close()
+
+			if err := loaded.Load(path); err != nil {
+				t.Fatal(err)
+			}
+
+			expected, _ := json.Marshal(saved.Habits)
+			actual, _ := json.Marshal(loaded.Habits)
+
+			if string(actual) != string(expected) {
+				t.Errorf("expected %s, got %s", expected, actual)
+			}
+
+			if loaded.NextID != saved.NextID || !loaded.UpdatedAt.Equal(saved.UpdatedAt) || loaded.TimeZone != saved.TimeZone {
+				t.Errorf("expected the settings of the data file to be loaded, got %+v", loaded)
+			}
+		})
+
+		t.Run(name+" changes the revision on save", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			store := OpenStore(path)
+			defer store.Close()
+
+			empty, _ := store.Revision()
+			habits := newSavedHabits(t, path)
+			defer habits.Close()
+			first, _ := store.Revision()
+			habits.Habits[0].CheckStep()
+			habits.Save(path)
+			second, _ := store.Revision()
+
+			if empty == first || first == second {
+				t.Errorf("expected different revisions, got %q, %q and %q", empty, first, second)
+			}
+		})
+
+		t.Run(name+" saves a habit", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			newSavedHabits(t, path).Close()
+			store := OpenStore(path)
+			defer store.Close()
+
+			habit := newHabit("Write", 1, 15, day)
+			habit.ID = 3
+			habit.Summary.History = []Entry{{Date: day, StepsCount: 1, StepMinutes: 15, CheckedSteps: 1}}
+
+			if err := store.SaveHabit(habit); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := NewHabits(clock.NewFake(day))
+			defer loaded.Close()
+			loaded.Load(path)
+
+			if len(loaded.Habits) != 3 || loaded.NextID != 4 || loaded.Habits[2].Summary.CurrentStreak != 1 {
+				t.Errorf("expected the habit to be added, got %+v (%d)", loaded.Habits, loaded.NextID)
+			}
+		})
+
+		t.Run(name+" appends an entry", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			newSavedHabits(t, path).Close()
+			store := OpenStore(path)
+			defer store.Close()
+
+			entry := Entry{Date: dayAfter(1), StepsCount: 2, StepMinutes: 30, CheckedSteps: 2}
+
+			if err := store.AppendEntry(1, entry); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.AppendEntry(7, entry); err == nil {
+				t.Error("expected an error for a missing habit")
+			}
+
+			history, err := store.History(1, day, dayAfter(1))
+
+			if err != nil || len(history) != 2 || !history[1].Date.Equal(dayAfter(1)) || history[1].CheckedSteps != 2 {
+				t.Errorf("expected the entry to be appended, got %v (%v)", history, err)
+			}
+		})
+
+		t.Run(name+" returns the history between the dates", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			newSavedHabits(t, path).Close()
+			store := OpenStore(path)
+			defer store.Close()
+
+			for n := range 5 {
+				store.AppendEntry(2, Entry{Date: dayAfter(n + 1), StepsCount: 1, StepMinutes: 60, CheckedSteps: int8(n % 2)})
+			}
+
+			history, err := store.History(2, dayAfter(2), dayAfter(4))
+
+			if err != nil || len(history) != 3 || !history[0].Date.Equal(dayAfter(2)) || !history[2].Date.Equal(dayAfter(4)) {
+				t.Errorf("expected 3 entries, got %v (%v)", history, err)
+			}
+
+			if _, err := store.History(7, day, dayAfter(4)); err == nil {
+				t.Error("expected an error for a missing habit")
+			}
+		})
+
+		t.Run(name+" runs the commands", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			fake := clock.NewFake(day.Add(12 * time.Hour))
+			habits := newHabitsAt(fake)
+			defer habits.Close()
+			habits.Load(path)
+			habits.Execute(command.NewCommand("a Read 1 30"))
+			habits.Execute(command.NewCommand("a Run 1 60"))
+			habits.Execute(command.NewCommand("c read"))
+			fake.AdvanceDays(2)
+			habits.Execute(command.NewCommand("log run -1 1"))
+			habits.Execute(command.NewCommand("d run --force"))
+			habits.Execute(command.NewCommand("c read"))
+
+			loaded := newHabitsAt(fake)
+			defer loaded.Close()
+			loaded.Load(path)
+
+			expected, _ := json.Marshal(habits.Habits)
+			actual, _ := json.Marshal(loaded.Habits)
+
+			if string(actual) != string(expected) {
+				t.Errorf("expected %s, got %s", expected, actual)
+			}
+
+			if loaded.EventSeq != habits.EventSeq {
+				t.Errorf("expected EventSeq to be %d, got %d", habits.EventSeq, loaded.EventSeq)
+			}
+		})
+
+		t.Run(name+" restores a backup", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			habits := newSavedHabits(t, path)
+			defer habits.Close()
+			habits.Execute(command.NewCommand("a Write 1 15"))
+			habits.Execute(command.NewCommand("d read --force"))
+
+			if err := habits.Execute(command.NewCommand("restore 1")); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(habits.Habits) != 3 || habits.Habits[0].Name != "Read" || len(habits.Habits[0].Summary.History) != 1 {
+				t.Errorf("expected the habits before the deletion, got %+v", habits.Habits)
+			}
+		})
+
+		t.Run(name+" reloads the habits changed by another process", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			first := NewHabits(clock.NewFake(day))
+			defer first.Close()
+			first.Load(path)
+			first.Execute(command.NewCommand("a Read 1 30"))
+
+			second := NewHabits(clock.NewFake(day))
+			defer second.Close()
+			second.Load(path)
+			second.Execute(command.NewCommand("c read"))
+
+			first.Execute(command.NewCommand("cs read 2"))
+
+			if habit := first.Habits[0]; habit.CheckedSteps != 1 || habit.StepsCount != 2 {
+				t.Errorf("expected the check of the other process to be kept, got %+v", habit)
+			}
+		})
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+
+	t.Run("reads the history of a period from the database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.db")
+		habits := newSavedHabits(t, path)
+		defer habits.Close()
+		habits.store.(*sqliteStore).db.Exec("UPDATE entries SET checked_steps = 2 WHERE habit_id = 1")
+
+		if history := habits.getHistory(habits.Habits[0], day, day); len(history) != 1 || history[0].CheckedSteps != 2 {
+			t.Errorf("expected the day to be read from the database, got %+v", history)
+		}
+
+		habits.Habits[0].CheckStep()
+		habits.appendEvent(Event{Type: EventChecked, Habit: 1, Steps: 1})
+
+		if history := habits.getHistory(habits.Habits[0], day, day); len(history) != 1 || history[0].CheckedSteps != 1 {
+			t.Errorf("expected the unsaved habits to be read, got %+v", history)
+		}
+	})
+
+	t.Run("writes only the changed days", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.db")
+		habits := newSavedHabits(t, path)
+		defer habits.Close()
+		store := habits.store.(*sqliteStore)
+
+		// A day changed behind the back of the store is kept, as it is not compared again
+		store.db.Exec("UPDATE entries SET checked_steps = 9 WHERE habit_id = 2")
+		habits.Habits[0].LogDay(day, 2)
+
+		if err := habits.Save(path); err != nil {
+			t.Fatal(err)
+		}
+
+		var read, run int
+		store.db.QueryRow("SELECT checked_steps FROM entries WHERE habit_id = 1").Scan(&read)
+		store.db.QueryRow("SELECT checked_steps FROM entries WHERE habit_id = 2").Scan(&run)
+
+		if read != 2 || run != 9 {
+			t.Errorf("expected only the logged day to be written, got %d and %d", read, run)
+		}
+	})
+
+	t.Run("removes the days of a restored history", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.db")
+		habits := newSavedHabits(t, path)
+		defer habits.Close()
+		habits.Habits[0].Summary.History = []Entry{}
+		habits.Save(path)
+
+		history, err := habits.store.History(1, day, dayAfter(1))
+
+		if err != nil || len(history) != 0 {
+			t.Errorf("expected an empty history, got %v (%v)", history, err)
+		}
+	})
+}
@@ -52,13 +52,17 @@ func (t TotalTime) Stringify() string {
 	return text
 }
 
-func (t *TotalTime) Add(minutes int16) {
-	totalMinutes := int16(t.Minutes) + minutes
+func (t TotalTime) InMinutes() int {
+	return (int(t.Days)*24+int(t.Hours))*60 + int(t.Minutes)
+}
+
+func (t *TotalTime) Add(minutes int) {
+	totalMinutes := int(t.Minutes) + minutes
 	newMinutes := int8(totalMinutes % 60)
-	extraHours := int8(totalMinutes / 60)
+	extraHours := totalMinutes / 60
 
-	totalHours := t.Hours + extraHours
-	newHours := totalHours % 24
+	totalHours := int(t.Hours) + extraHours
+	newHours := int8(totalHours % 24)
 	extraDays := totalHours / 24
 
 	newDays := t.Days + int16(extraDays)
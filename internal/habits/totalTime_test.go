@@ -38,7 +38,7 @@ func TestStringify(t *testing.T) {
 func TestAdd(t *testing.T) {
 	var tests = []struct {
 		initial TotalTime
-		minutes int16
+		minutes int
 		want    TotalTime
 	}{
 		{TotalTime{Minutes: 0}, 10, TotalTime{Minutes: 10}},
@@ -46,6 +46,7 @@ func TestAdd(t *testing.T) {
 		{TotalTime{Minutes: 0}, 70, TotalTime{Minutes: 10, Hours: 1}},
 		{TotalTime{Hours: 0}, 24 * 60, TotalTime{Days: 1}},
 		{TotalTime{Hours: 23, Minutes: 0}, 130, TotalTime{Days: 1, Hours: 1, Minutes: 10}},
+		{TotalTime{Hours: 23, Minutes: 50}, 127 * 960, TotalTime{Days: 85, Hours: 15, Minutes: 50}},
 	}
 
 	for _, tt := range tests {
@@ -86,3 +87,23 @@ func TestSubtract(t *testing.T) {
 		})
 	}
 }
+
+func TestInMinutes(t *testing.T) {
+	var tests = []struct {
+		total TotalTime
+		want  int
+	}{
+		{TotalTime{}, 0},
+		{TotalTime{Minutes: 59}, 59},
+		{TotalTime{Days: 2, Hours: 1, Minutes: 10}, 2*24*60 + 70},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("converts the total time to minutes", func(t *testing.T) {
+			if got := tt.total.InMinutes(); got != tt.want {
+				t.Errorf("invalid minutes of %s, expected: %d, got: %d", tt.total.Stringify(), tt.want, got)
+			}
+		})
+	}
+}
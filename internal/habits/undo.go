@@ -0,0 +1,289 @@
+package habits
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/storage"
+	"github.com/seektor/habits-tracker-go/internal/utils"
+)
+
+// UndoHistoryLen is the number of the last operations which can be undone.
+const UndoHistoryLen = 20
+
+const journalSuffix = ".undo"
+
+// Operation is a change of the habits made by a command, kept as the events which undo and redo it.
+type Operation struct {
+	Command   string
+	CreatedAt time.Time
+	Undo      []Event
+	Redo      []Event
+}
+
+// habitsState is the part of the habits which can be changed by a command.
+type habitsState struct {
+	Habits      []Habit
+	TimeZone    string
+	DayStartsAt DayStart
+}
+
+// journal holds the operations which can be undone and redone, next to the data file,
+// so they survive restarts.
+type journal struct {
+	Done   []Operation
+	Undone []Operation
+}
+
+func loadJournal(path string) (*journal, error) {
+	j := &journal{Done: []Operation{}, Undone: []Operation{}}
+	file, err := os.ReadFile(path + journalSuffix)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(file, j); err != nil {
+		return nil, err
+	}
+
+	// The operations of older versions, kept as whole data files, cannot be undone anymore
+	isLegacy := func(op Operation) bool { return op.Undo == nil || op.Redo == nil }
+	j.Done, j.Undone = slices.DeleteFunc(j.Done, isLegacy), slices.DeleteFunc(j.Undone, isLegacy)
+
+	return j, nil
+}
+
+func (j *journal) save(path string) error {
+	data, err := json.Marshal(j)
+
+	if err != nil {
+		return err
+	}
+
+	return storage.WriteFileAtomic(path+journalSuffix, data, 0644)
+}
+
+// record adds the operation and forgets the undone ones, they cannot be redone after a new change.
+func (j *journal) record(op Operation) {
+	j.Done = append(j.Done, op)
+	j.Done = j.Done[max(0, len(j.Done)-UndoHistoryLen):]
+	j.Undone = []Operation{}
+}
+
+// captureState copies the habits together with their histories, so the change of a command can be found.
+func (h *Habits) captureState() habitsState {
+	state := habitsState{Habits: slices.Clone(h.Habits), TimeZone: h.TimeZone, DayStartsAt: h.DayStartsAt}
+
+	for idx := range state.Habits {
+		state.Habits[idx].Summary.History = slices.Clone(state.Habits[idx].Summary.History)
+	}
+
+	return state
+}
+
+// savepoint is the in-memory state of the habits, to be put back when a change fails partway.
+type savepoint struct {
+	state     habitsState
+	nextID    int
+	updatedAt time.Time
+	location  *time.Location
+	eventSeq  int
+	pending   []Event
+}
+
+func (h *Habits) savepoint() savepoint {
+	return savepoint{state: h.captureState(), nextID: h.NextID, updatedAt: h.UpdatedAt, location: h.location, eventSeq: h.EventSeq, pending: slices.Clone(h.pending)}
+}
+
+// rollback drops the changes and the events made after the savepoint, e.g. by a failed command.
+func (h *Habits) rollback(s savepoint) {
+	h.Habits, h.NextID, h.UpdatedAt, h.EventSeq, h.pending = s.state.Habits, s.nextID, s.updatedAt, s.eventSeq, s.pending
+	h.TimeZone, h.DayStartsAt, h.location = s.state.TimeZone, s.state.DayStartsAt, s.location
+}
+
+// encodeSettings returns the habit without the summary which is recalculated from the history.
+func encodeSettings(habit Habit) ([]byte, error) {
+	habit.Summary = Summary{Baseline: habit.Summary.Baseline}
+
+	return json.Marshal(habit)
+}
+
+// getDayEvents returns the events which turn the history into the other one, both ordered by date.
+func getDayEvents(habitID int, from, to []Entry) []Event {
+	events := []Event{}
+	i, j := 0, 0
+
+	for i < len(from) || j < len(to) {
+		switch {
+		case j == len(to) || i < len(from) && from[i].Date.Before(to[j].Date):
+			events = append(events, Event{Type: EventDayRemoved, Habit: habitID, Date: &from[i].Date})
+			i += 1
+		case i == len(from) || to[j].Date.Before(from[i].Date):
+			events = append(events, Event{Type: EventDayImported, Habit: habitID, Entry: &to[j]})
+			j += 1
+		default:
+			// The dates are equal, only the rest of the day is compared
+			a, b := from[i], to[j]
+			a.Date, b.Date = time.Time{}, time.Time{}
+
+			if a != b {
+				events = append(events, Event{Type: EventDayImported, Habit: habitID, Entry: &to[j]})
+			}
+
+			i, j = i+1, j+1
+		}
+	}
+
+	return events
+}
+
+// getChangeEvents returns the events which turn the state into the other one. A changed habit is restored
+// without its history as it was on its current day, only the changed days of the history are kept.
+func (h *Habits) getChangeEvents(from, to habitsState) ([]Event, error) {
+	events := []Event{}
+
+	if from.TimeZone != to.TimeZone {
+		events = append(events, Event{Type: EventTimeZoneChanged, TimeZone: to.TimeZone})
+	}
+
+	if from.DayStartsAt != to.DayStartsAt {
+		events = append(events, Event{Type: EventDayStartChanged, DayStart: &to.DayStartsAt})
+	}
+
+	for _, habit := range from.Habits {
+		if !slices.ContainsFunc(to.Habits, func(item Habit) bool { return item.ID == habit.ID }) {
+			events = append(events, Event{Type: EventDeleted, Habit: habit.ID})
+		}
+	}
+
+	for _, habit := range to.Habits {
+		idx := slices.IndexFunc(from.Habits, func(item Habit) bool { return item.ID == habit.ID })
+
+		if idx < 0 {
+			state, err := json.Marshal(habit)
+
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, Event{Type: EventHabitRestored, Habit: habit.ID, State: state})
+			continue
+		}
+
+		state, err := encodeSettings(habit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prev, err := encodeSettings(from.Habits[idx])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(prev, state) {
+			date := h.today(&habit)
+			events = append(events, Event{Type: EventHabitRestored, Habit: habit.ID, Date: &date, State: state})
+		}
+
+		events = append(events, getDayEvents(habit.ID, from.Habits[idx].Summary.History, habit.Summary.History)...)
+	}
+
+	return events, nil
+}
+
+// record keeps the change made by the running command. The change has already been saved,
+// so a failure is only reported.
+func (h *Habits) record(before habitsState) {
+	after := h.captureState()
+	undo, err := h.getChangeEvents(after, before)
+
+	if err == nil {
+		var redo []Event
+
+		if redo, err = h.getChangeEvents(before, after); err == nil {
+			var j *journal
+
+			if j, err = loadJournal(h.path); err == nil {
+				j.record(Operation{Command: h.running, CreatedAt: h.clock.Now(), Undo: undo, Redo: redo})
+				err = j.save(h.path)
+			}
+		}
+	}
+
+	if err != nil {
+		utils.PrintlnError("Change cannot be undone: " + err.Error())
+	}
+}
+
+// travel moves the last undone or done operation to the other list and applies its events, which restore
+// the changed habits and days. The days which have passed since then are closed again.
+func (h *Habits) travel(isUndo bool) error {
+	if h.path == "" {
+		return errors.New("data file has not been set")
+	}
+
+	return h.withLock(func() error {
+		if err := h.sync(); err != nil {
+			return err
+		}
+
+		j, err := loadJournal(h.path)
+
+		if err != nil {
+			return fmt.Errorf("undo history cannot be read: %w", err)
+		}
+
+		from, to, verb := &j.Done, &j.Undone, "undone"
+		if !isUndo {
+			from, to, verb = &j.Undone, &j.Done, "redone"
+		}
+
+		if len(*from) == 0 {
+			return fmt.Errorf("there is nothing to be %s", verb)
+		}
+
+		op := (*from)[len(*from)-1]
+		events := op.Undo
+		if !isUndo {
+			events = op.Redo
+		}
+
+		saved := h.savepoint()
+
+		for _, e := range events {
+			if err := h.emit(e); err != nil {
+				h.rollback(saved)
+				return fmt.Errorf("habits cannot be restored: %w", err)
+			}
+		}
+
+		h.UpdateToPresent()
+
+		if err := h.Save(h.path); err != nil {
+			return fmt.Errorf("changes have not been saved: %w", err)
+		}
+
+		*from = (*from)[:len(*from)-1]
+		*to = append(*to, op)
+
+		if err := j.save(h.path); err != nil {
+			return fmt.Errorf("undo history has not been saved: %w", err)
+		}
+
+		utils.PrintlnSuccess(fmt.Sprintf("%q has been %s", op.Command, verb))
+
+		return nil
+	})
+}
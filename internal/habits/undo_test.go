@@ -0,0 +1,255 @@
+package habits
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seektor/habits-tracker-go/internal/clock"
+	"github.com/seektor/habits-tracker-go/internal/command"
+)
+
+func TestUndo(t *testing.T) {
+
+	t.Run("undoes and redoes a deletion", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("d test"))
+
+		if err := habits.Execute(command.NewCommand("undo")); err != nil || len(habits.Habits) != 1 || habits.Habits[0].CheckedSteps != 1 {
+			t.Fatalf("deletion has not been undone: %v (%v)", habits.Habits, err)
+		}
+
+		if err := habits.Execute(command.NewCommand("redo")); err != nil || len(habits.Habits) != 0 {
+			t.Errorf("deletion has not been redone: %v (%v)", habits.Habits, err)
+		}
+	})
+
+	t.Run("works across restarts", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		first := NewHabits(clock.NewFake(day))
+		first.Load(path)
+		first.Execute(command.NewCommand("a Test 1 60"))
+		first.Execute(command.NewCommand("cs test 3"))
+
+		second := NewHabits(clock.NewFake(day))
+		second.Load(path)
+		second.Execute(command.NewCommand("undo"))
+
+		third := NewHabits(clock.NewFake(day))
+		third.Load(path)
+
+		if third.Habits[0].StepsCount != 1 {
+			t.Errorf("expected StepsCount to be %d, got %d", 1, third.Habits[0].StepsCount)
+		}
+	})
+
+	t.Run("keeps the last operations", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		for range UndoHistoryLen {
+			habits.Execute(command.NewCommand("c test"))
+		}
+
+		for range UndoHistoryLen {
+			if err := habits.Execute(command.NewCommand("undo")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(habits.Habits) != 1 || habits.Habits[0].CheckedSteps != 0 {
+			t.Errorf("expected the habit without checks, got %v", habits.Habits)
+		}
+
+		if err := habits.Execute(command.NewCommand("undo")); err == nil {
+			t.Error("expected the creation to be forgotten")
+		}
+	})
+
+	t.Run("forgets the undone operations after a new change", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("undo"))
+		habits.Execute(command.NewCommand("ct test 30"))
+
+		if err := habits.Execute(command.NewCommand("redo")); err == nil {
+			t.Error("expected nothing to be redone")
+		}
+	})
+
+	t.Run("closes the days which have passed since the operation", func(t *testing.T) {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("c test"))
+		habits.Execute(command.NewCommand("cs test 2"))
+		fake.AdvanceDays(1)
+
+		habits.Execute(command.NewCommand("undo"))
+
+		habit := habits.Habits[0]
+		if habit.StepsCount != 1 || len(habit.Summary.History) != 1 || habit.Summary.CurrentStreak != 1 {
+			t.Errorf("expected the day to be closed with the undone steps count: %+v", habit)
+		}
+	})
+
+	t.Run("records only the changed habits and days", func(t *testing.T) {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Read 1 30"))
+		habits.Execute(command.NewCommand("a Run 1 60"))
+		fake.AdvanceDays(3)
+		habits.Execute(command.NewCommand("c read"))
+		habits.Execute(command.NewCommand("log run -2 1"))
+
+		j, _ := loadJournal(habits.Path())
+		check, log := j.Done[len(j.Done)-2], j.Done[len(j.Done)-1]
+
+		if len(check.Undo) != 1 || check.Undo[0].Type != EventHabitRestored || check.Undo[0].Habit != 1 || strings.Contains(string(check.Undo[0].State), "Date") {
+			t.Errorf("expected only Read to be restored without its history, got %+v", check.Undo)
+		}
+
+		if len(log.Undo) != 1 || log.Undo[0].Type != EventDayImported || log.Undo[0].Entry.CheckedSteps != 0 || len(log.Redo) != 1 {
+			t.Errorf("expected only the logged day to be restored, got %+v and %+v", log.Undo, log.Redo)
+		}
+
+		habits.Execute(command.NewCommand("undo"))
+
+		if entry := habits.Habits[1].Summary.History[1]; entry.CheckedSteps != 0 || habits.Habits[0].CheckedSteps != 1 {
+			t.Errorf("expected only the logged day to be undone, got %+v", habits.Habits)
+		}
+
+		habits.Execute(command.NewCommand("redo"))
+
+		if habits.Habits[1].Summary.History[1].CheckedSteps != 1 {
+			t.Errorf("expected the logged day to be redone, got %+v", habits.Habits[1].Summary.History)
+		}
+	})
+
+	t.Run("undoes a check of a day which has been closed since", func(t *testing.T) {
+		fake := clock.NewFake(day.Add(12 * time.Hour))
+		habits := newHabitsAt(fake)
+		habits.Load(filepath.Join(t.TempDir(), "habits.json"))
+		habits.Execute(command.NewCommand("a Read 1 30"))
+		habits.Execute(command.NewCommand("a Run 1 60"))
+		habits.Execute(command.NewCommand("c read"))
+		fake.AdvanceDays(1)
+		habits.Execute(command.NewCommand("c read"))
+		habits.Execute(command.NewCommand("c run"))
+		habits.Execute(command.NewCommand("undo"))
+		habits.Execute(command.NewCommand("undo"))
+
+		if err := habits.Execute(command.NewCommand("undo")); err != nil {
+			t.Fatal(err)
+		}
+
+		read := habits.Habits[0]
+		if read.Summary.History[0].CheckedSteps != 0 || read.CheckedSteps != 0 || read.Summary.CurrentStreak != 0 {
+			t.Errorf("expected the closed day to be unchecked, got %+v", read)
+		}
+	})
+
+	t.Run("drops the operations of older versions", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		os.WriteFile(habits.Path()+journalSuffix, []byte(`{"Done":[{"Command":"a Test 1 60","Before":{},"After":{}}],"Undone":[]}`), 0644)
+
+		if err := habits.Execute(command.NewCommand("undo")); err == nil || len(habits.Habits) != 1 {
+			t.Errorf("expected nothing to be undone, got %v", habits.Habits)
+		}
+	})
+
+	t.Run("rolls back an operation which fails partway", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Execute(command.NewCommand("c test"))
+
+		j, _ := loadJournal(habits.Path())
+		j.Done[1].Undo = append(j.Done[1].Undo, Event{Type: EventDeleted, Habit: 7})
+		j.save(habits.Path())
+
+		if err := habits.Execute(command.NewCommand("undo")); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		if habits.Habits[0].CheckedSteps != 1 || len(habits.pending) != 0 || habits.EventSeq != 3 {
+			t.Errorf("expected the undo to be rolled back, got %+v (%d, %v)", habits.Habits, habits.EventSeq, habits.pending)
+		}
+	})
+
+	t.Run("rolls back a command which fails partway", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+
+		err := habits.mutate("", func() error {
+			habits.emit(Event{Type: EventCreated, Name: "Read", StepsCount: 1, StepMinutes: 30})
+			habits.emitForHabit("test", Event{Type: EventChecked, Steps: 1})
+
+			return habits.emitForHabit("missing", Event{Type: EventChecked, Steps: 1})
+		})
+
+		if err == nil || len(habits.Habits) != 1 || habits.Habits[0].CheckedSteps != 0 || habits.NextID != 2 || len(habits.pending) != 0 {
+			t.Errorf("expected the command to be rolled back, got %+v (%v)", habits.Habits, habits.pending)
+		}
+
+		habits.Execute(command.NewCommand("c test"))
+		events, _ := readEvents(habits.Path())
+
+		if len(events) != 3 || events[2].Type != EventChecked {
+			t.Errorf("expected only the next command to be logged, got %v", events)
+		}
+	})
+
+	t.Run("does not record a failed command", func(t *testing.T) {
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("d missing"))
+
+		if _, err := os.Stat(habits.Path() + journalSuffix); err == nil {
+			t.Error("failed command has been recorded")
+		}
+	})
+}
+
+func TestDeleteConfirmation(t *testing.T) {
+	newConfirmedHabits := func(t *testing.T, answer bool) (*Habits, *[]string) {
+		questions := []string{}
+		habits := newTestHabits(t)
+		habits.Execute(command.NewCommand("a Test 1 60"))
+		habits.Confirm = func(question string) bool {
+			questions = append(questions, question)
+			return answer
+		}
+
+		return habits, &questions
+	}
+
+	t.Run("does not delete a habit without a confirmation", func(t *testing.T) {
+		habits, questions := newConfirmedHabits(t, false)
+
+		if err := habits.Execute(command.NewCommand("d test")); err == nil || len(habits.Habits) != 1 || len(*questions) != 1 {
+			t.Errorf("habit has been deleted without a confirmation: %v", err)
+		}
+	})
+
+	t.Run("deletes a confirmed habit", func(t *testing.T) {
+		habits, _ := newConfirmedHabits(t, true)
+
+		if err := habits.Execute(command.NewCommand("d test")); err != nil || len(habits.Habits) != 0 {
+			t.Errorf("habit has not been deleted: %v", err)
+		}
+	})
+
+	t.Run("does not ask when forced", func(t *testing.T) {
+		habits, questions := newConfirmedHabits(t, false)
+
+		if err := habits.Execute(command.NewCommand("d test --force")); err != nil || len(habits.Habits) != 0 || len(*questions) != 0 {
+			t.Errorf("habit has not been deleted without asking: %v", err)
+		}
+	})
+}
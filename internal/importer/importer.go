@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultStepMinutes is the time of a step of the trackers which do not track time.
+const DefaultStepMinutes int16 = 30
+
+// Habit is a habit read from another tracker, independent of the habits package.
+type Habit struct {
+	Name        string
+	StepsCount  int8 // daily goal, 1 for a habit which is only done or not done
+	StepMinutes int16
+	Schedule    string    // in the format of the schedule argument of the add command, e.g. daily, 3d or 3/w
+	Start       time.Time // the day an interval is counted from, the first day when zero
+	HasOffDays  bool      // the days tell whether they were due, instead of the schedule
+	Days        []Day     // ordered by date
+	Warnings    []string  // what could not be imported as it is, e.g. an unsupported frequency
+}
+
+// Day is a tracked day of a habit.
+type Day struct {
+	Date         time.Time
+	StepsCount   int8
+	CheckedSteps int8
+	Minutes      int // time of the checked steps
+	IsFrozen     bool
+	IsOffDay     bool   // the day was not due, e.g. it was covered by the frequency of the habit
+	Source       string // where the day has been read from, e.g. line 3, used in the reports
+}
+
+type Options struct {
+	StepMinutes int16 // time of a step when the tracker does not track it
+}
+
+// Importer reads the backup or the export of another tracker.
+type Importer interface {
+	// Name is used as the format of the import command, e.g. loop.
+	Name() string
+	Desc() string
+	Read(path string, options Options) ([]Habit, error)
+}
+
+var importers = []Importer{}
+
+// Register makes the importer available to the import command, it is called by the init of the importer.
+func Register(importer Importer) {
+	importers = append(importers, importer)
+}
+
+func Get(name string) (Importer, error) {
+	idx := slices.IndexFunc(importers, func(importer Importer) bool { return importer.Name() == name })
+
+	if idx < 0 {
+		return nil, fmt.Errorf("unknown importer %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+
+	return importers[idx], nil
+}
+
+func Names() []string {
+	names := []string{}
+
+	for _, importer := range importers {
+		names = append(names, importer.Name())
+	}
+
+	return names
+}
+
+// sortDays orders the days by date, the trackers often export the newest days first.
+func sortDays(days []Day) {
+	slices.SortStableFunc(days, func(a, b Day) int { return a.Date.Compare(b.Date) })
+}
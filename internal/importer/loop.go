@@ -0,0 +1,307 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(loop{})
+}
+
+// Values of the checkmarks of Loop, the numeric ones are written by the older versions.
+// The values of a numerical habit are multiplied by 1000.
+const (
+	loopUnknown   = -1
+	loopNo        = 0
+	loopYesAuto   = 1 // not checked, the day is covered by the frequency of the habit
+	loopYesManual = 2
+	loopSkip      = 3
+)
+
+var loopValues = map[string]int{
+	"UNKNOWN":    loopUnknown,
+	"NO":         loopNo,
+	"YES_AUTO":   loopYesAuto,
+	"YES_MANUAL": loopYesManual,
+	"SKIP":       loopSkip,
+}
+
+// loop reads the CSV export of Loop Habit Tracker, a zip file or its extracted directory
+// with Habits.csv and Checkmarks.csv.
+type loop struct{}
+
+type loopHabit struct {
+	Name        string
+	Schedule    string
+	Warnings    []string
+	IsNumerical bool
+	Target      float64
+}
+
+func (loop) Name() string {
+	return "loop"
+}
+
+func (loop) Desc() string {
+	return "CSV export of Loop Habit Tracker, the zip file or its extracted directory"
+}
+
+func (l loop) Read(path string, options Options) ([]Habit, error) {
+	habitsFile, err := readExportFile(path, "Habits.csv")
+
+	if err != nil {
+		return nil, err
+	}
+
+	loopHabits, err := readLoopHabits(habitsFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("Habits.csv: %w", err)
+	}
+
+	checkmarksFile, err := readExportFile(path, "Checkmarks.csv")
+
+	if err != nil {
+		return nil, err
+	}
+
+	habits, err := readLoopCheckmarks(checkmarksFile, loopHabits, options)
+
+	if err != nil {
+		return nil, fmt.Errorf("Checkmarks.csv: %w", err)
+	}
+
+	return habits, nil
+}
+
+// readExportFile reads a file from the top level of the export, the habit directories
+// of a Loop export have files of the same names.
+func readExportFile(path string, name string) ([]byte, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return os.ReadFile(filepath.Join(path, name))
+	}
+
+	archive, err := zip.OpenReader(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a directory nor a zip file: %w", path, err)
+	}
+
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+
+		reader, err := file.Open()
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+
+	return nil, fmt.Errorf("%s has not been found in %s", name, path)
+}
+
+func readCSVRecords(data []byte) ([][]string, map[string]int, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, errors.New("file is empty")
+	}
+
+	columns := map[string]int{}
+	for idx, name := range records[0] {
+		columns[strings.TrimSpace(name)] = idx
+	}
+
+	return records[1:], columns, nil
+}
+
+// getField returns the value of the first present column, the column names differ between the versions of Loop.
+func getField(record []string, columns map[string]int, names ...string) string {
+	for _, name := range names {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+	}
+
+	return ""
+}
+
+func readLoopHabits(data []byte) ([]loopHabit, error) {
+	records, columns, err := readCSVRecords(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := columns["Name"]; !ok {
+		return nil, errors.New("missing column Name")
+	}
+
+	habits := []loopHabit{}
+
+	for _, record := range records {
+		numerator, _ := strconv.Atoi(getField(record, columns, "FrequencyNumerator", "NumRepetitions"))
+		denominator, _ := strconv.Atoi(getField(record, columns, "FrequencyDenominator", "Interval"))
+		target, _ := strconv.ParseFloat(getField(record, columns, "Target Value", "TargetValue"), 64)
+		schedule, ok := getLoopSchedule(numerator, denominator)
+		warnings := []string{}
+
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("the frequency of %d times in %d days is not supported, it is imported as %s", numerator, denominator, schedule))
+		}
+
+		habits = append(habits, loopHabit{
+			Name:        getField(record, columns, "Name"),
+			Schedule:    schedule,
+			Warnings:    warnings,
+			IsNumerical: getField(record, columns, "Type") == "1",
+			Target:      target,
+		})
+	}
+
+	return habits, nil
+}
+
+// getLoopSchedule maps the frequency of Loop, n times in m days, onto a schedule.
+// False is returned when the frequency has no schedule and daily is used instead.
+func getLoopSchedule(numerator, denominator int) (string, bool) {
+	switch {
+	case numerator == 1 && denominator > 1:
+		return fmt.Sprintf("%dd", denominator), true
+	case denominator == 7 && numerator > 1 && numerator < 7:
+		return fmt.Sprintf("%d/w", numerator), true
+	default:
+		return "daily", numerator == denominator
+	}
+}
+
+func parseLoopValue(value string) (int, error) {
+	if number, ok := loopValues[strings.ToUpper(value)]; ok {
+		return number, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+// readLoopCheckmarks reads a row per date with a column per habit. The days before the first known
+// checkmark of a habit are skipped, a skipped day is frozen and a day covered by the frequency is an off day.
+// The interval of a habit is counted from its last check, as the window of Loop rolls with the checks.
+func readLoopCheckmarks(data []byte, loopHabits []loopHabit, options Options) ([]Habit, error) {
+	records, columns, err := readCSVRecords(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stepMinutes := options.StepMinutes
+	if stepMinutes <= 0 {
+		stepMinutes = DefaultStepMinutes
+	}
+
+	habits := []Habit{}
+
+	for _, loopHabit := range loopHabits {
+		column, ok := columns[loopHabit.Name]
+
+		if !ok {
+			continue
+		}
+
+		habit := Habit{
+			Name:        loopHabit.Name,
+			StepsCount:  1,
+			StepMinutes: stepMinutes,
+			Schedule:    loopHabit.Schedule,
+			HasOffDays:  true,
+			Days:        []Day{},
+			Warnings:    loopHabit.Warnings,
+		}
+		unknown := map[time.Time]bool{}
+
+		for idx, record := range records {
+			if column >= len(record) || strings.TrimSpace(record[column]) == "" {
+				continue
+			}
+
+			line := idx + 2
+			date, err := time.Parse(time.DateOnly, strings.TrimSpace(record[0]))
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid date %q", line, record[0])
+			}
+
+			value, err := parseLoopValue(strings.TrimSpace(record[column]))
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q of %s", line, record[column], loopHabit.Name)
+			}
+
+			day := Day{Date: date, StepsCount: 1, Source: fmt.Sprintf("line %d", line)}
+
+			switch {
+			case value == loopUnknown:
+				unknown[date] = true
+			case loopHabit.IsNumerical:
+				if float64(value)/1000 >= max(loopHabit.Target, 0.001) {
+					day.CheckedSteps = 1
+				}
+			case value == loopYesManual:
+				day.CheckedSteps = 1
+			case value == loopYesAuto:
+				day.IsOffDay = true
+			case value == loopSkip:
+				day.StepsCount, day.IsFrozen = 0, true
+			}
+
+			day.Minutes = int(day.CheckedSteps) * int(stepMinutes)
+			habit.Days = append(habit.Days, day)
+		}
+
+		sortDays(habit.Days)
+
+		// The unknown days before the first known one precede the habit, the later ones are missed
+		for len(habit.Days) > 0 && unknown[habit.Days[0].Date] {
+			habit.Days = habit.Days[1:]
+		}
+
+		for _, day := range habit.Days {
+			if day.CheckedSteps > 0 && strings.HasSuffix(habit.Schedule, "d") {
+				habit.Start = day.Date
+			}
+		}
+
+		habits = append(habits, habit)
+	}
+
+	return habits, nil
+}
@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const loopHabitsCSV = "Position,Name,Type,Question,Description,FrequencyNumerator,FrequencyDenominator,Color,Unit,Target Type,Target Value,Archived?\n" +
+	"001,Read,0,,,1,1,#000000,,0,0,false\n" +
+	"002,Run,0,,,3,7,#000000,,0,0,false\n" +
+	"003,Water,1,,,1,1,#000000,l,0,2,false\n" +
+	"004,Clean,0,,,1,3,#000000,,0,0,false\n"
+
+const loopCheckmarksCSV = "Date,Read,Run,Water,Clean,\n" +
+	"2020-11-22,YES_MANUAL,NO,2500,UNKNOWN,\n" +
+	"2020-11-21,SKIP,YES_AUTO,1000,UNKNOWN,\n" +
+	"2020-11-20,2,YES_MANUAL,UNKNOWN,UNKNOWN,\n" +
+	"2020-11-19,UNKNOWN,UNKNOWN,UNKNOWN,UNKNOWN,\n"
+
+func writeLoopExport(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func writeLoopZip(t *testing.T, files map[string]string) string {
+	path := filepath.Join(t.TempDir(), "Loop Habits CSV.zip")
+	file, err := os.Create(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+	archive := zip.NewWriter(file)
+
+	for name, content := range files {
+		writer, err := archive.Create(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		writer.Write([]byte(content))
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
+
+func TestLoopRead(t *testing.T) {
+	files := map[string]string{
+		"Habits.csv":          loopHabitsCSV,
+		"Checkmarks.csv":      loopCheckmarksCSV,
+		"001 Read/Habits.csv": "Date,Value\n",
+	}
+
+	t.Run("reads an extracted export", func(t *testing.T) {
+		habits, err := loop{}.Read(writeLoopExport(t, map[string]string{"Habits.csv": loopHabitsCSV, "Checkmarks.csv": loopCheckmarksCSV}), Options{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(habits) != 4 {
+			t.Fatalf("expected 4 habits, got %+v", habits)
+		}
+
+		schedules := []string{habits[0].Schedule, habits[1].Schedule, habits[2].Schedule, habits[3].Schedule}
+		if strings.Join(schedules, " ") != "daily 3/w daily 3d" {
+			t.Errorf("expected the frequencies to be mapped onto the schedules, got %v", schedules)
+		}
+
+		read := habits[0]
+		if len(read.Days) != 3 || !read.Days[0].Date.Equal(date("2020-11-20")) || read.Days[0].CheckedSteps != 1 || read.Days[0].Minutes != int(DefaultStepMinutes) {
+			t.Errorf("expected the days to be sorted and the leading unknown day to be dropped, got %+v", read.Days)
+		}
+
+		if !read.Days[1].IsFrozen || read.Days[2].CheckedSteps != 1 {
+			t.Errorf("expected the skipped day to be frozen, got %+v", read.Days)
+		}
+
+		run := habits[1]
+		if run.Days[1].CheckedSteps != 0 || !run.Days[1].IsOffDay || run.Days[2].CheckedSteps != 0 || run.Days[2].IsOffDay {
+			t.Errorf("expected the automatic day to be an off day and the unchecked one to be due, got %+v", run.Days)
+		}
+
+		water := habits[2]
+		if len(water.Days) != 2 || water.Days[0].CheckedSteps != 0 || water.Days[1].CheckedSteps != 1 {
+			t.Errorf("expected the numerical habit to be checked when the target is reached, got %+v", water.Days)
+		}
+
+		if len(habits[3].Days) != 0 {
+			t.Errorf("expected a habit without known days to have no days, got %+v", habits[3].Days)
+		}
+	})
+
+	t.Run("reads a zip file", func(t *testing.T) {
+		habits, err := loop{}.Read(writeLoopZip(t, files), Options{StepMinutes: 15})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(habits) != 4 || habits[0].StepMinutes != 15 || habits[0].Days[0].Minutes != 15 {
+			t.Errorf("expected the habits to be read with the step time, got %+v", habits)
+		}
+	})
+
+	t.Run("reads the older column names", func(t *testing.T) {
+		dir := writeLoopExport(t, map[string]string{
+			"Habits.csv":     "Position,Name,Description,NumRepetitions,Interval,Color\n001,Read,,1,2,#000000\n",
+			"Checkmarks.csv": "Date,Read,\n2020-11-20,2,\n",
+		})
+		habits, err := loop{}.Read(dir, Options{})
+
+		if err != nil || len(habits) != 1 || habits[0].Schedule != "2d" || habits[0].Days[0].CheckedSteps != 1 || !habits[0].Start.Equal(date("2020-11-20")) {
+			t.Errorf("expected the older export to be read and the interval to start on the last check, got %+v (%v)", habits, err)
+		}
+	})
+
+	t.Run("reports the invalid files", func(t *testing.T) {
+		var tests = []struct {
+			name  string
+			files map[string]string
+			err   string
+		}{
+			{"missing checkmarks", map[string]string{"Habits.csv": loopHabitsCSV}, "Checkmarks.csv"},
+			{"missing name", map[string]string{"Habits.csv": "Position\n", "Checkmarks.csv": loopCheckmarksCSV}, "missing column Name"},
+			{"invalid date", map[string]string{"Habits.csv": loopHabitsCSV, "Checkmarks.csv": "Date,Read\n20.11.2020,2\n"}, "line 2: invalid date"},
+			{"invalid value", map[string]string{"Habits.csv": loopHabitsCSV, "Checkmarks.csv": "Date,Read\n2020-11-20,MAYBE\n"}, "line 2: invalid value"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := loop{}.Read(writeLoopExport(t, tt.files), Options{})
+
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected an error containing %q, got %v", tt.err, err)
+				}
+			})
+		}
+	})
+
+	t.Run("rejects a file which is not a zip file", func(t *testing.T) {
+		path := filepath.Join(writeLoopExport(t, map[string]string{"Habits.csv": loopHabitsCSV}), "Habits.csv")
+
+		if _, err := (loop{}).Read(path, Options{}); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+func TestGetLoopSchedule(t *testing.T) {
+	var tests = []struct {
+		numerator   int
+		denominator int
+		schedule    string
+		ok          bool
+	}{
+		{1, 1, "daily", true},
+		{1, 3, "3d", true},
+		{3, 7, "3/w", true},
+		{3, 10, "daily", false},
+		{7, 7, "daily", true},
+	}
+
+	for _, tt := range tests {
+		if schedule, ok := getLoopSchedule(tt.numerator, tt.denominator); schedule != tt.schedule || ok != tt.ok {
+			t.Errorf("expected %d in %d days to be %s (%v), got %s (%v)", tt.numerator, tt.denominator, tt.schedule, tt.ok, schedule, ok)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if imp, err := Get("loop"); err != nil || imp.Name() != "loop" {
+		t.Errorf("expected the loop importer, got %v (%v)", imp, err)
+	}
+
+	if _, err := Get("xls"); err == nil {
+		t.Errorf("expected an unknown importer to be rejected")
+	}
+}
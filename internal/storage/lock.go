@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const DefaultLockTimeout = 5 * time.Second
+
+const lockRetryInterval = 50 * time.Millisecond
+
+var ErrLocked = errors.New("data file is locked by another process")
+
+// FileLock is an advisory lock of a data file, held on a lock file next to it.
+type FileLock struct {
+	file *os.File
+}
+
+// Lock waits until the lock of the data file is acquired or the timeout passes.
+func Lock(path string, timeout time.Duration) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		isLocked, err := tryLock(file)
+
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if isLocked {
+			return &FileLock{file}, nil
+		}
+
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, ErrLocked
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (l *FileLock) Unlock() error {
+	unlockErr := unlock(l.file)
+	closeErr := l.file.Close()
+
+	return errors.Join(unlockErr, closeErr)
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package storage
+
+import "os"
+
+// Platforms without file locking rely on the change detection of the data file only.
+func tryLock(file *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
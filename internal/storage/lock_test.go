@@ -0,0 +1,48 @@
+//go:build unix || windows
+
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+
+	t.Run("refuses a lock which is held", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		lock, err := Lock(path, time.Second)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer lock.Unlock()
+
+		_, err = Lock(path, 100*time.Millisecond)
+
+		if !errors.Is(err, ErrLocked) {
+			t.Errorf("expected a locked error, got %v", err)
+		}
+	})
+
+	t.Run("acquires a released lock", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		lock, _ := Lock(path, time.Second)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			lock.Unlock()
+		}()
+
+		other, err := Lock(path, time.Second)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		other.Unlock()
+	})
+}
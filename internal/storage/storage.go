@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const BackupsDir = "backups"
+const DefaultBackupsCount = 10
+
+const backupTimeLayout = "20060102T150405.000000000Z"
+
+type Backup struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// WriteFileAtomic writes the data to a temporary file next to the target and renames it into place,
+// so the target holds either the old or the new content even when the write is interrupted.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+
+	if err != nil {
+		return err
+	}
+
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tempPath, perm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// AppendFile appends the data to the file and flushes it to the disk, the file is created when missing.
+func AppendFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// syncDir persists the rename, not every platform supports syncing a directory.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	file.Sync()
+
+	return nil
+}
+
+func getBackupPrefix(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "."
+}
+
+// CreateBackup copies the current content of the file to the backups directory next to it
+// and removes the oldest backups above the keep count. A missing file is not backed up.
+func CreateBackup(path string, keep int) error {
+	file, err := os.Open(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+
+	if err != nil {
+		return err
+	}
+
+	backupPath, err := NewBackupPath(path)
+
+	if err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(backupPath, data, 0644); err != nil {
+		return err
+	}
+
+	return RemoveOldBackups(path, keep)
+}
+
+// NewBackupPath returns the path of a new backup of the file, the backups directory is created when missing.
+func NewBackupPath(path string) (string, error) {
+	dir := filepath.Join(filepath.Dir(path), BackupsDir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := getBackupPrefix(path) + time.Now().UTC().Format(backupTimeLayout) + filepath.Ext(path)
+
+	return filepath.Join(dir, name), nil
+}
+
+// RemoveOldBackups removes the oldest backups of the file above the keep count.
+func RemoveOldBackups(path string, keep int) error {
+	backups, err := ListBackups(path)
+
+	if err != nil {
+		return err
+	}
+
+	for _, backup := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(backup.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateVersionBackup keeps the content of the file in the version it had before a migration.
+// An existing backup of the version is not overwritten.
+func CreateVersionBackup(path string, data []byte, version int) error {
+	name := getBackupPrefix(path) + fmt.Sprintf("v%d", version) + filepath.Ext(path)
+	backupPath := filepath.Join(filepath.Dir(path), BackupsDir, name)
+
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+
+	return WriteFileAtomic(backupPath, data, 0644)
+}
+
+// ListBackups returns the backups of the file, the newest first.
+func ListBackups(path string) ([]Backup, error) {
+	dir := filepath.Join(filepath.Dir(path), BackupsDir)
+	entries, err := os.ReadDir(dir)
+
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := getBackupPrefix(path)
+	backups := []Backup{}
+
+	for _, entry := range entries {
+		timestamp, ok := strings.CutPrefix(entry.Name(), prefix)
+
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		createdAt, err := time.Parse(backupTimeLayout, strings.TrimSuffix(timestamp, filepath.Ext(path)))
+
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Path: filepath.Join(dir, entry.Name()), CreatedAt: createdAt})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return backups, nil
+}
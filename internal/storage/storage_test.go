@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+
+	t.Run("replaces the content of the file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "habits.json")
+		os.WriteFile(path, []byte("old"), 0644)
+
+		err := WriteFileAtomic(path, []byte("new"), 0644)
+		data, _ := os.ReadFile(path)
+
+		if err != nil || string(data) != "new" {
+			t.Errorf("expected the content to be %q, got %q (%v)", "new", data, err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("expected only the target file in the directory, got %d files", len(entries))
+		}
+	})
+
+	t.Run("creates the missing directories", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habit-tracker", "habits.json")
+		err := WriteFileAtomic(path, []byte("new"), 0644)
+
+		if _, statErr := os.Stat(path); err != nil || statErr != nil {
+			t.Errorf("file has not been created: %v", err)
+		}
+	})
+}
+
+func TestAppendFile(t *testing.T) {
+
+	t.Run("creates the file and appends to it", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "log", "events")
+		AppendFile(path, []byte("a\n"), 0644)
+		AppendFile(path, []byte("b\n"), 0644)
+
+		data, err := os.ReadFile(path)
+
+		if err != nil || string(data) != "a\nb\n" {
+			t.Errorf("expected %q, got %q (%v)", "a\nb\n", data, err)
+		}
+	})
+}
+
+func TestCreateBackup(t *testing.T) {
+
+	t.Run("does not back up a missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+		err := CreateBackup(path, 3)
+		backups, _ := ListBackups(path)
+
+		if err != nil || len(backups) != 0 {
+			t.Errorf("expected no backups, got %d (%v)", len(backups), err)
+		}
+	})
+
+	t.Run("keeps the newest backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "habits.json")
+
+		for i := range 5 {
+			os.WriteFile(path, []byte(strconv.Itoa(i)), 0644)
+
+			if err := CreateBackup(path, 3); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		backups, _ := ListBackups(path)
+
+		if len(backups) != 3 {
+			t.Fatalf("expected %d backups, got %d", 3, len(backups))
+		}
+
+		for idx, want := range []string{"4", "3", "2"} {
+			data, _ := os.ReadFile(backups[idx].Path)
+
+			if string(data) != want {
+				t.Errorf("expected backup %d to contain %q, got %q", idx, want, data)
+			}
+		}
+	})
+
+	t.Run("lists only the backups of the file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "default.json")
+		otherPath := filepath.Join(dir, "work.json")
+		os.WriteFile(path, []byte("default"), 0644)
+		os.WriteFile(otherPath, []byte("work"), 0644)
+		CreateBackup(path, 3)
+		CreateBackup(otherPath, 3)
+
+		backups, _ := ListBackups(path)
+
+		if len(backups) != 1 {
+			t.Errorf("expected %d backup, got %d", 1, len(backups))
+		}
+	})
+}
+
+func TestCreateVersionBackup(t *testing.T) {
+
+	t.Run("keeps the first backup of a version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "default.json")
+		CreateVersionBackup(path, []byte("first"), 1)
+		CreateVersionBackup(path, []byte("second"), 1)
+
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(path), BackupsDir, "default.v1.json"))
+
+		if err != nil || string(data) != "first" {
+			t.Errorf("expected the backup to contain %q, got %q (%v)", "first", data, err)
+		}
+
+		if backups, _ := ListBackups(path); len(backups) != 0 {
+			t.Errorf("version backup should not be listed, got %d backups", len(backups))
+		}
+	})
+}
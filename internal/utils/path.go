@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	AppName        = "habit-tracker"
+	DefaultProfile = "default"
+	DataEnv        = "HABIT_TRACKER_DATA"
+	ProfileEnv     = "HABIT_TRACKER_PROFILE"
+)
+
+// LegacyFileName is the data file which was stored in the current directory by older versions.
+const LegacyFileName = "habits_tracker.json"
+
+// GetDataPath resolves the data file of a profile. The location is taken from,
+// in order: the data argument, the HABIT_TRACKER_DATA variable and $XDG_DATA_HOME/habit-tracker.
+// A location which is a directory holds one file per profile.
+func GetDataPath(data string, profile string) (string, error) {
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	if strings.ContainsAny(profile, `/\`) || strings.HasPrefix(profile, ".") {
+		return "", errors.New("profile name cannot contain path separators or start with a dot")
+	}
+
+	if data == "" {
+		data = os.Getenv(DataEnv)
+	}
+
+	if data != "" {
+		if info, err := os.Stat(data); err == nil && info.IsDir() {
+			return filepath.Join(data, profile+".json"), nil
+		}
+
+		return data, nil
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+
+	if !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return "", err
+		}
+
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, AppName, profile+".json"), nil
+}
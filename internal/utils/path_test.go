@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetDataPath(t *testing.T) {
+	dir := t.TempDir()
+
+	var tests = []struct {
+		name    string
+		data    string
+		profile string
+		env     map[string]string
+		want    string
+	}{
+		{"uses XDG_DATA_HOME", "", "", map[string]string{"XDG_DATA_HOME": "/data"}, "/data/habit-tracker/default.json"},
+		{"falls back to ~/.local/share", "", "", map[string]string{"HOME": "/home/user"}, "/home/user/.local/share/habit-tracker/default.json"},
+		{"uses a profile", "", "work", map[string]string{"XDG_DATA_HOME": "/data"}, "/data/habit-tracker/work.json"},
+		{"uses the profile variable", "", "", map[string]string{"XDG_DATA_HOME": "/data", ProfileEnv: "home"}, "/data/habit-tracker/home.json"},
+		{"prefers the profile argument", "", "work", map[string]string{"XDG_DATA_HOME": "/data", ProfileEnv: "home"}, "/data/habit-tracker/work.json"},
+		{"uses the data variable", "", "", map[string]string{DataEnv: "/tmp/habits.json"}, "/tmp/habits.json"},
+		{"prefers the data argument", "/tmp/other.json", "", map[string]string{DataEnv: "/tmp/habits.json"}, "/tmp/other.json"},
+		{"uses a data directory per profile", dir, "work", map[string]string{}, filepath.Join(dir, "work.json")},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", "")
+			t.Setenv(DataEnv, "")
+			t.Setenv(ProfileEnv, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := GetDataPath(tt.data, tt.profile)
+
+			if err != nil || got != tt.want {
+				t.Errorf("invalid data path, expected: %s, got: %s (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	t.Run("returns an error for a profile with a path separator", func(t *testing.T) {
+		_, err := GetDataPath("", "../work")
+
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
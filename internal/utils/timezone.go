@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GetLocalTimeZone returns the IANA name of the local time zone, taken from the TZ variable
+// or the /etc/localtime link. It returns "Local" when the name cannot be found.
+func GetLocalTimeZone() string {
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		tz = strings.TrimPrefix(tz, ":")
+
+		if tz == "" {
+			return "UTC"
+		}
+
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(filepath.ToSlash(target), "zoneinfo/"); ok {
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+
+	return time.Local.String()
+}
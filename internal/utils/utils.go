@@ -5,8 +5,6 @@ import (
 	"time"
 )
 
-const FileName = "habits_tracker.json"
-
 var FgColors = struct {
 	Reset  string
 	Yellow string
@@ -23,16 +21,45 @@ var FgColors = struct {
 	Bold:   "\033[1m",
 }
 
-func getBeginningOfDayDate(t time.Time) time.Time {
+// GetBeginningOfDayDate returns the calendar date of t, in the location of t, as midnight UTC.
+func GetBeginningOfDayDate(t time.Time) time.Time {
 	year, month, day := t.Date()
 	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
 
+// GetBeginningOfWeekDate returns the date of the Monday of the week.
+func GetBeginningOfWeekDate(t time.Time) time.Time {
+	date := GetBeginningOfDayDate(t)
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+}
+
+// GetDaysDiff returns the number of calendar days between the dates of from and to,
+// each date is taken in the location of its time.
 func GetDaysDiff(from time.Time, to time.Time) int32 {
-	fromBeginning := getBeginningOfDayDate(from)
-	toBeginning := getBeginningOfDayDate(to)
+	fromBeginning := GetBeginningOfDayDate(from)
+	toBeginning := GetBeginningOfDayDate(to)
+
+	// Both are midnights UTC, so every day is exactly 24 hours long
+	return int32(toBeginning.Sub(fromBeginning) / (24 * time.Hour))
+}
+
+// GetDayDate returns the date of the day which t belongs to in the location, when the days
+// begin dayStart minutes after midnight. The boundary is a wall-clock time, so it stays the same over DST changes.
+func GetDayDate(t time.Time, loc *time.Location, dayStart int) time.Time {
+	t = t.In(loc)
+	date := GetBeginningOfDayDate(t)
+
+	if t.Hour()*60+t.Minute() < dayStart {
+		date = date.AddDate(0, 0, -1)
+	}
+
+	return date
+}
 
-	return int32(toBeginning.Sub(fromBeginning).Hours() / 24)
+// GetDaysDiffIn returns the number of days between from and to in the location, when the days
+// begin dayStart minutes after midnight, regardless of the locations the times have been recorded in.
+func GetDaysDiffIn(from time.Time, to time.Time, loc *time.Location, dayStart int) int32 {
+	return GetDaysDiff(GetDayDate(from, loc, dayStart), GetDayDate(to, loc, dayStart))
 }
 
 func ColorString(color string, msg string) string {
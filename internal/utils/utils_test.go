@@ -3,6 +3,7 @@ package utils
 import (
 	"testing"
 	"time"
+	_ "time/tzdata"
 )
 
 func TestGetDaysDiff(t *testing.T) {
@@ -36,3 +37,116 @@ func TestGetDaysDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestGetDaysDiffIn(t *testing.T) {
+	warsaw, _ := time.LoadLocation("Europe/Warsaw")
+	newYork, _ := time.LoadLocation("America/New_York")
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	var tests = []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		loc      *time.Location
+		dayStart int
+		want     int32
+	}{
+		{"spring forward, a day of 23 hours",
+			time.Date(2021, 3, 28, 0, 30, 0, 0, warsaw),
+			time.Date(2021, 3, 29, 0, 0, 0, 0, warsaw),
+			warsaw, 0, 1},
+		{"spring forward, the same day",
+			time.Date(2021, 3, 28, 0, 30, 0, 0, warsaw),
+			time.Date(2021, 3, 28, 23, 59, 0, 0, warsaw),
+			warsaw, 0, 0},
+		{"fall back, a day of 25 hours",
+			time.Date(2021, 11, 7, 0, 0, 0, 0, newYork),
+			time.Date(2021, 11, 7, 23, 59, 0, 0, newYork),
+			newYork, 0, 0},
+		{"fall back, the next day",
+			time.Date(2021, 11, 7, 0, 0, 0, 0, newYork),
+			time.Date(2021, 11, 8, 0, 0, 0, 0, newYork),
+			newYork, 0, 1},
+		{"a week over the DST change",
+			time.Date(2021, 3, 25, 12, 0, 0, 0, warsaw),
+			time.Date(2021, 4, 1, 12, 0, 0, 0, warsaw),
+			warsaw, 0, 7},
+		{"times recorded in another zone",
+			time.Date(2020, 11, 20, 20, 0, 0, 0, newYork),
+			time.Date(2020, 11, 21, 10, 0, 0, 0, warsaw),
+			warsaw, 0, 0},
+		{"times recorded in UTC",
+			time.Date(2020, 11, 20, 14, 0, 0, 0, time.UTC),
+			time.Date(2020, 11, 20, 16, 0, 0, 0, time.UTC),
+			tokyo, 0, 1},
+		{"before the day start",
+			time.Date(2020, 11, 20, 22, 0, 0, 0, warsaw),
+			time.Date(2020, 11, 21, 0, 30, 0, 0, warsaw),
+			warsaw, 4 * 60, 0},
+		{"after the day start",
+			time.Date(2020, 11, 20, 22, 0, 0, 0, warsaw),
+			time.Date(2020, 11, 21, 4, 0, 0, 0, warsaw),
+			warsaw, 4 * 60, 1},
+		{"the day start on the DST change",
+			time.Date(2021, 3, 27, 23, 0, 0, 0, warsaw),
+			time.Date(2021, 3, 28, 3, 30, 0, 0, warsaw),
+			warsaw, 4 * 60, 0},
+		{"the same instant in different zones",
+			time.Date(2020, 11, 20, 23, 0, 0, 0, warsaw),
+			time.Date(2020, 11, 20, 23, 0, 0, 0, warsaw).In(tokyo),
+			newYork, 0, 0},
+	}
+
+	for _, tt := range tests {
+
+		t.Run(tt.name, func(t *testing.T) {
+			diff := GetDaysDiffIn(tt.from, tt.to, tt.loc, tt.dayStart)
+
+			if diff != tt.want {
+				t.Errorf("invalid days difference, expected: %d, got: %d", tt.want, diff)
+			}
+		})
+	}
+}
+
+func TestGetLocalTimeZone(t *testing.T) {
+
+	t.Run("takes the zone from the TZ variable", func(t *testing.T) {
+		t.Setenv("TZ", "Asia/Tokyo")
+
+		if zone := GetLocalTimeZone(); zone != "Asia/Tokyo" {
+			t.Errorf("expected %s, got %s", "Asia/Tokyo", zone)
+		}
+	})
+
+	t.Run("treats an empty TZ variable as UTC", func(t *testing.T) {
+		t.Setenv("TZ", "")
+
+		if zone := GetLocalTimeZone(); zone != "UTC" {
+			t.Errorf("expected %s, got %s", "UTC", zone)
+		}
+	})
+}
+
+func TestGetBeginningOfWeekDate(t *testing.T) {
+	var tests = []struct {
+		date time.Time
+		want time.Time
+	}{
+		{time.Date(2020, 11, 16, 12, 34, 0, 0, time.UTC), time.Date(2020, 11, 16, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 11, 20, 12, 34, 0, 0, time.UTC), time.Date(2020, 11, 16, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 11, 22, 23, 59, 0, 0, time.UTC), time.Date(2020, 11, 16, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+
+		t.Run("returns the monday of the week", func(t *testing.T) {
+			got := GetBeginningOfWeekDate(tt.date)
+
+			if got != tt.want {
+				t.Errorf("invalid beginning of the week, expected: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}